@@ -5,12 +5,15 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/stream"
 	"github.com/yakumioto/dipper/types"
 	"github.com/yakumioto/dipper/utils"
 )
@@ -19,10 +22,80 @@ var (
 	ErrUnsupportedMethod = errors.New("aes: unsupported method")
 )
 
-type CbcKeyImpl[T types.DataType] struct {
+// WithRawKey uses the imported key bytes as the AES key as-is, instead of deriving the key with
+// utils.ExtendKey, so that ciphertexts interoperate with other implementations.
+// The key must have the exact length required by the algorithm. Use WithBase64RawKey to import the
+// base64 keys returned by Export.
+func WithRawKey[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		if mk, ok := k.(materialKey); ok {
+			return mk.material().setRawKey(mk.material().inputKey)
+		}
+		return errors.New("aes: invalid key type")
+	}
+}
+
+// WithBase64RawKey is like WithRawKey, but the imported key is the unpadded base64 encoding of the
+// AES key, as returned by Export for raw and generated keys. It does nothing on keys that are
+// already raw, such as generated keys.
+func WithBase64RawKey[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		if mk, ok := k.(materialKey); ok {
+			m := mk.material()
+			if m.raw {
+				return nil
+			}
+
+			rawKey, err := base64.RawStdEncoding.DecodeString(string(m.inputKey))
+			if err != nil {
+				return fmt.Errorf("aes: invalid base64 raw key: %w", err)
+			}
+			return m.setRawKey(rawKey)
+		}
+		return errors.New("aes: invalid key type")
+	}
+}
+
+// keyMaterial holds the key shared by every AES mode. inputKey is the imported key and
+// extendKey is the AES key actually used by the cipher. They are the same for raw keys.
+type keyMaterial struct {
 	inputKey  []byte
 	extendKey []byte
+	raw       bool
 	algorithm types.Algorithm
+	deriver   key.Deriver
+}
+
+func (m *keyMaterial) material() *keyMaterial {
+	return m
+}
+
+// setRawKey uses rawKey as the AES key, which must have the exact length required by the algorithm.
+func (m *keyMaterial) setRawKey(rawKey []byte) error {
+	if len(rawKey) != len(m.extendKey) {
+		return fmt.Errorf("aes: invalid raw key length for %s: want %d bytes, got %d",
+			m.algorithm, len(m.extendKey), len(rawKey))
+	}
+
+	m.inputKey, m.extendKey, m.raw = rawKey, rawKey, true
+	return nil
+}
+
+// export returns the key returned by Export. Raw keys are binary and are encoded with unpadded
+// base64, which WithBase64RawKey accepts.
+func (m *keyMaterial) export() []byte {
+	if m.raw {
+		return []byte(base64.RawStdEncoding.EncodeToString(m.inputKey))
+	}
+	return m.inputKey
+}
+
+type materialKey interface {
+	material() *keyMaterial
+}
+
+type CbcKeyImpl[T types.DataType] struct {
+	keyMaterial
 }
 
 func (a *CbcKeyImpl[T]) Algorithm() types.Algorithm {
@@ -30,7 +103,7 @@ func (a *CbcKeyImpl[T]) Algorithm() types.Algorithm {
 }
 
 func (a *CbcKeyImpl[T]) Export() (key T, err error) {
-	return T(a.inputKey), nil
+	return T(a.export()), nil
 }
 
 func (a *CbcKeyImpl[T]) SKI() T {
@@ -119,13 +192,16 @@ func (a *CbcKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
 	paddedText := make([]byte, len(ciphertextBytes))
 	mode.CryptBlocks(paddedText, ciphertextBytes)
 
-	return utils.Pkcs7UnPadding(T(paddedText)), nil
+	plaintext, err := utils.Pkcs7UnPadding(T(paddedText), aes.BlockSize)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-cbc: decrypt failed to unpad plaintext: %w", err)
+	}
+
+	return plaintext, nil
 }
 
 type GcmKeyImpl[T types.DataType] struct {
-	inputKey  []byte
-	extendKey []byte
-	algorithm types.Algorithm
+	keyMaterial
 }
 
 func (a *GcmKeyImpl[T]) Algorithm() types.Algorithm {
@@ -133,7 +209,7 @@ func (a *GcmKeyImpl[T]) Algorithm() types.Algorithm {
 }
 
 func (a *GcmKeyImpl[T]) Export() (key T, err error) {
-	return T(a.inputKey), nil
+	return T(a.export()), nil
 }
 
 func (a *GcmKeyImpl[T]) SKI() T {
@@ -156,6 +232,10 @@ func (a *GcmKeyImpl[T]) Verify(_, _ T) (bool, error) {
 }
 
 func (a *GcmKeyImpl[T]) Encrypt(plaintext T) (T, error) {
+	return a.EncryptWithAAD(plaintext, T(""))
+}
+
+func (a *GcmKeyImpl[T]) EncryptWithAAD(plaintext, additionalData T) (T, error) {
 	block, err := aes.NewCipher(a.extendKey)
 	if err != nil {
 		return T(""), fmt.Errorf("aes-gcm: new aes cipher error: %w", err)
@@ -171,7 +251,7 @@ func (a *GcmKeyImpl[T]) Encrypt(plaintext T) (T, error) {
 		return T(""), fmt.Errorf("aes-gcm: failed to generate random nonce: %w", err)
 	}
 
-	sealedData := gcm.Seal(nil, nonce, utils.ToBytes(plaintext), nil)
+	sealedData := gcm.Seal(nil, nonce, utils.ToBytes(plaintext), utils.ToBytes(additionalData))
 
 	payload := make([]byte, 0, len(nonce)+len(sealedData))
 	payload = append(payload, nonce...)
@@ -186,6 +266,10 @@ func (a *GcmKeyImpl[T]) Encrypt(plaintext T) (T, error) {
 }
 
 func (a *GcmKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
+	return a.DecryptWithAAD(ciphertext, T(""))
+}
+
+func (a *GcmKeyImpl[T]) DecryptWithAAD(ciphertext, additionalData T) (T, error) {
 	dataBytes := utils.ToString(ciphertext)
 
 	parts := strings.SplitN(dataBytes, ".", 2)
@@ -220,7 +304,7 @@ func (a *GcmKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
 
 	nonce, ciphertextBytes := encryptedPayload[:gcm.NonceSize()], encryptedPayload[gcm.NonceSize():]
 
-	decryptedData, err := gcm.Open(nil, nonce, ciphertextBytes, nil)
+	decryptedData, err := gcm.Open(nil, nonce, ciphertextBytes, utils.ToBytes(additionalData))
 	if err != nil {
 		return T(""), fmt.Errorf("aes-gcm: failed to decrypt data: %w", err)
 	}
@@ -228,6 +312,58 @@ func (a *GcmKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
 	return T(decryptedData), nil
 }
 
+// NewEncryptingWriter returns a writer that encrypts everything written to it into w with the
+// STREAM construction of the stream package. Close must be called to finish the stream.
+func (a *GcmKeyImpl[T]) NewEncryptingWriter(w io.Writer) (io.WriteCloser, error) {
+	return stream.NewWriter(w, a.algorithm, a.extendKey, newGCM)
+}
+
+// NewDecryptingReader returns a reader that decrypts a stream written by NewEncryptingWriter.
+func (a *GcmKeyImpl[T]) NewDecryptingReader(r io.Reader) (io.Reader, error) {
+	return stream.NewReader(r, a.algorithm, a.extendKey, newGCM)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func init() {
+	for _, alg := range []types.Algorithm{
+		types.AesCbc128, types.AesCbc192, types.AesCbc256, types.AesGcm128, types.AesGcm192, types.AesGcm256,
+		types.AesCbcHmac128, types.AesCbcHmac192, types.AesCbcHmac256, types.AesGcmSiv128, types.AesGcmSiv256,
+		types.AesSiv256, types.AesSiv384, types.AesSiv512,
+		types.AesCtr128, types.AesCtr192, types.AesCtr256, types.AesCfb128, types.AesCfb192, types.AesCfb256,
+		types.AesOfb128, types.AesOfb192, types.AesOfb256,
+		types.AesKw128, types.AesKw192, types.AesKw256, types.AesKwp128, types.AesKwp192, types.AesKwp256,
+	} {
+		key.Register[string](alg, new(KeyImportImpl[string]), new(KeyGeneratorImpl[string]))
+		key.Register[[]byte](alg, new(KeyImportImpl[[]byte]), new(KeyGeneratorImpl[[]byte]))
+	}
+}
+
+// KeyGeneratorImpl generates random raw AES keys of the key size. The generated key is exported as
+// a base64 string and can be imported again with KeyImport and WithBase64RawKey.
+type KeyGeneratorImpl[T types.DataType] struct{}
+
+func (a *KeyGeneratorImpl[T]) KeyGen(alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
+	keyLen := keySize(alg)
+	if keyLen == 0 {
+		return nil, fmt.Errorf("aes: invalid algorithm: %v", alg)
+	}
+
+	keyBytes, err := utils.RandomSize(keyLen)
+	if err != nil {
+		return nil, fmt.Errorf("aes: failed to generate random key: %w", err)
+	}
+
+	return new(KeyImportImpl[T]).KeyImport(keyBytes, alg, append([]key.Option[T]{WithRawKey[T]()}, opts...)...)
+}
+
 type KeyImportImpl[T types.DataType] struct{}
 
 func (a *KeyImportImpl[T]) KeyImport(raw interface{}, alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
@@ -236,25 +372,87 @@ func (a *KeyImportImpl[T]) KeyImport(raw interface{}, alg types.Algorithm, opts
 		return nil, fmt.Errorf("aes: key import failed to convert key: %w", err)
 	}
 
-	var keyLen int
-	switch alg {
-	case types.AesCbc128, types.AesGcm128:
-		keyLen = 128 / 8
-	case types.AesCbc192, types.AesGcm192:
-		keyLen = 192 / 8
-	case types.AesCbc256, types.AesGcm256:
-		keyLen = 256 / 8
-	default:
+	keyLen := keySize(alg)
+	if keyLen == 0 {
 		return nil, fmt.Errorf("aes: invalid algorithm: %v", alg)
 	}
 
 	extendKey := utils.ExtendKey(keyBytes, keyLen)
 
+	k := newKeyImpl[T](keyMaterial{algorithm: alg, inputKey: keyBytes, extendKey: extendKey})
+
+	for _, opt := range opts {
+		if err = opt(k); err != nil {
+			return nil, err
+		}
+	}
+
+	if sk, ok := k.(*UnauthenticatedKeyImpl[T]); ok && !sk.unauthenticated {
+		return nil, fmt.Errorf("aes: %v is unauthenticated and must be imported with WithUnauthenticated", alg)
+	}
+
+	if m := k.(materialKey).material(); m.deriver != nil {
+		return newPasswordKeyImpl[T](alg, keyBytes, keyLen, m.deriver), nil
+	}
+
+	if err = deriveSubkeys(k); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// keySize returns the AES key size in bytes for alg, or 0 if alg is not an AES algorithm.
+func keySize(alg types.Algorithm) int {
 	switch alg {
+	case types.AesCbc128, types.AesGcm128, types.AesCbcHmac128, types.AesGcmSiv128,
+		types.AesCtr128, types.AesCfb128, types.AesOfb128, types.AesKw128, types.AesKwp128:
+		return 128 / 8
+	case types.AesCbc192, types.AesGcm192, types.AesCbcHmac192,
+		types.AesCtr192, types.AesCfb192, types.AesOfb192, types.AesKw192, types.AesKwp192:
+		return 192 / 8
+	case types.AesCbc256, types.AesGcm256, types.AesCbcHmac256, types.AesGcmSiv256, types.AesSiv256,
+		types.AesCtr256, types.AesCfb256, types.AesOfb256, types.AesKw256, types.AesKwp256:
+		return 256 / 8
+	case types.AesSiv384:
+		return 384 / 8
+	case types.AesSiv512:
+		return 512 / 8
+	default:
+		return 0
+	}
+}
+
+// deriveSubkeys derives the keys that modes such as AES-CBC-HMAC use instead of the AES key
+// itself.
+func deriveSubkeys[T types.DataType](k key.Key[T]) error {
+	if ck, ok := k.(*CbcHmacKeyImpl[T]); ok {
+		return ck.deriveKeys()
+	}
+	return nil
+}
+
+func newKeyImpl[T types.DataType](m keyMaterial) key.Key[T] {
+	switch m.algorithm {
 	case types.AesCbc128, types.AesCbc192, types.AesCbc256:
-		return &CbcKeyImpl[T]{algorithm: alg, inputKey: keyBytes, extendKey: extendKey}, nil
+		return &CbcKeyImpl[T]{keyMaterial: m}
 	case types.AesGcm128, types.AesGcm192, types.AesGcm256:
-		return &GcmKeyImpl[T]{algorithm: alg, inputKey: keyBytes, extendKey: extendKey}, nil
+		return &GcmKeyImpl[T]{keyMaterial: m}
+	case types.AesGcmSiv128, types.AesGcmSiv256:
+		return &GcmSivKeyImpl[T]{keyMaterial: m}
+	case types.AesSiv256, types.AesSiv384, types.AesSiv512:
+		return &SivKeyImpl[T]{keyMaterial: m}
+	case types.AesCtr128, types.AesCtr192, types.AesCtr256,
+		types.AesCfb128, types.AesCfb192, types.AesCfb256,
+		types.AesOfb128, types.AesOfb192, types.AesOfb256:
+		return &UnauthenticatedKeyImpl[T]{keyMaterial: m}
+	case types.AesKw128, types.AesKw192, types.AesKw256,
+		types.AesKwp128, types.AesKwp192, types.AesKwp256:
+		return &KwKeyImpl[T]{keyMaterial: m}
+	case types.AesCbcHmac128, types.AesCbcHmac192:
+		return &CbcHmacKeyImpl[T]{keyMaterial: m, macFunc: sha256.New}
+	case types.AesCbcHmac256:
+		return &CbcHmacKeyImpl[T]{keyMaterial: m, macFunc: sha512.New}
 	default:
 		panic("unhandled default case")
 	}
@@ -1,10 +1,19 @@
 package aes
 
 import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/pbkdf2"
 	"github.com/yakumioto/dipper/types"
 )
 
@@ -127,6 +136,30 @@ func TestEncryptAndDecrypt(t *testing.T) {
 		{
 			algorithm: types.AesGcm256,
 		},
+		{
+			algorithm: types.AesCbcHmac128,
+		},
+		{
+			algorithm: types.AesCbcHmac192,
+		},
+		{
+			algorithm: types.AesCbcHmac256,
+		},
+		{
+			algorithm: types.AesGcmSiv128,
+		},
+		{
+			algorithm: types.AesGcmSiv256,
+		},
+		{
+			algorithm: types.AesSiv256,
+		},
+		{
+			algorithm: types.AesSiv384,
+		},
+		{
+			algorithm: types.AesSiv512,
+		},
 	}
 
 	for _, tc := range tcs {
@@ -145,3 +178,450 @@ func TestEncryptAndDecrypt(t *testing.T) {
 		assert.Equal(t, "hello world", plaintext, "Decrypt failed")
 	}
 }
+
+func TestEncryptAndDecryptWithAAD(t *testing.T) {
+	tcs := []struct {
+		algorithm types.Algorithm
+	}{
+		{
+			algorithm: types.AesGcm128,
+		},
+		{
+			algorithm: types.AesGcm192,
+		},
+		{
+			algorithm: types.AesGcm256,
+		},
+		{
+			algorithm: types.AesCbcHmac128,
+		},
+		{
+			algorithm: types.AesCbcHmac192,
+		},
+		{
+			algorithm: types.AesCbcHmac256,
+		},
+		{
+			algorithm: types.AesGcmSiv128,
+		},
+		{
+			algorithm: types.AesGcmSiv256,
+		},
+		{
+			algorithm: types.AesSiv256,
+		},
+		{
+			algorithm: types.AesSiv384,
+		},
+		{
+			algorithm: types.AesSiv512,
+		},
+	}
+
+	for _, tc := range tcs {
+		ki := new(KeyImportImpl[string])
+
+		k, err := ki.KeyImport("123456", tc.algorithm)
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		aead, ok := k.(key.AEADKey[string])
+		assert.Truef(t, ok, "%s does not support associated data", tc.algorithm)
+
+		ct, err := aead.EncryptWithAAD("hello world", "row-1")
+		assert.NoErrorf(t, err, "EncryptWithAAD failed: %s", err)
+
+		plaintext, err := aead.DecryptWithAAD(ct, "row-1")
+		assert.NoErrorf(t, err, "DecryptWithAAD failed: %s", err)
+		assert.Equal(t, "hello world", plaintext, "DecryptWithAAD failed")
+
+		_, err = aead.DecryptWithAAD(ct, "row-2")
+		assert.Error(t, err, "DecryptWithAAD with wrong associated data should fail")
+
+		_, err = aead.Decrypt(ct)
+		assert.Error(t, err, "Decrypt without associated data should fail")
+
+		ct, err = aead.Encrypt("hello world")
+		assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+
+		plaintext, err = aead.DecryptWithAAD(ct, "")
+		assert.NoErrorf(t, err, "DecryptWithAAD failed: %s", err)
+		assert.Equal(t, "hello world", plaintext, "DecryptWithAAD failed")
+	}
+}
+
+func TestCbcHmacTamperedCiphertext(t *testing.T) {
+	ki := new(KeyImportImpl[[]byte])
+
+	k, err := ki.KeyImport("123456", types.AesCbcHmac256)
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	ct, err := k.Encrypt([]byte("hello world"))
+	assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+
+	prefix := []byte(types.AesCbcHmac256 + ".")
+	payload, err := base64.RawStdEncoding.DecodeString(string(ct[len(prefix):]))
+	assert.NoErrorf(t, err, "DecodeString failed: %s", err)
+
+	for _, i := range []int{0, aes.BlockSize, len(payload) - 1} {
+		tampered := bytes.Clone(payload)
+		tampered[i] ^= 0x01
+
+		_, err = k.Decrypt(append(bytes.Clone(prefix), base64.RawStdEncoding.EncodeToString(tampered)...))
+		assert.EqualError(t, err, "aes-cbc-hmac: message authentication failed", "Decrypt tampered ciphertext")
+	}
+}
+
+func TestCbcCorruptedCiphertext(t *testing.T) {
+	ki := new(KeyImportImpl[string])
+
+	k, err := ki.KeyImport("123456", types.AesCbc128)
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	iv := make([]byte, aes.BlockSize)
+
+	_, err = k.Decrypt(types.AesCbc128 + "." + base64.RawStdEncoding.EncodeToString(iv))
+	assert.Error(t, err, "Decrypt empty ciphertext should fail")
+
+	for i := 0; i < 256; i++ {
+		payload := append(bytes.Clone(iv), bytes.Repeat([]byte{byte(i)}, aes.BlockSize)...)
+
+		assert.NotPanics(t, func() {
+			_, _ = k.Decrypt(types.AesCbc128 + "." + base64.RawStdEncoding.EncodeToString(payload))
+		}, "Decrypt corrupted ciphertext")
+	}
+}
+
+func TestSivDeterministic(t *testing.T) {
+	ki := new(KeyImportImpl[string])
+
+	k, err := ki.KeyImport("123456", types.AesSiv512)
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	aead := k.(key.AEADKey[string])
+
+	ct1, err := aead.EncryptWithAAD("alice@example.com", "users.email")
+	assert.NoErrorf(t, err, "EncryptWithAAD failed: %s", err)
+
+	ct2, err := aead.EncryptWithAAD("alice@example.com", "users.email")
+	assert.NoErrorf(t, err, "EncryptWithAAD failed: %s", err)
+	assert.Equal(t, ct1, ct2, "EncryptWithAAD is not deterministic")
+
+	ct3, err := aead.EncryptWithAAD("alice@example.com", "users.backup_email")
+	assert.NoErrorf(t, err, "EncryptWithAAD failed: %s", err)
+	assert.NotEqual(t, ct1, ct3, "EncryptWithAAD ignored associated data")
+
+	ct4, err := aead.EncryptWithAAD("bob@example.com", "users.email")
+	assert.NoErrorf(t, err, "EncryptWithAAD failed: %s", err)
+	assert.NotEqual(t, ct1, ct4, "EncryptWithAAD ignored plaintext")
+}
+
+func TestStreamEncryptAndDecrypt(t *testing.T) {
+	tcs := []struct {
+		algorithm types.Algorithm
+	}{
+		{
+			algorithm: types.AesCtr128,
+		},
+		{
+			algorithm: types.AesCtr192,
+		},
+		{
+			algorithm: types.AesCtr256,
+		},
+		{
+			algorithm: types.AesCfb128,
+		},
+		{
+			algorithm: types.AesCfb192,
+		},
+		{
+			algorithm: types.AesCfb256,
+		},
+		{
+			algorithm: types.AesOfb128,
+		},
+		{
+			algorithm: types.AesOfb192,
+		},
+		{
+			algorithm: types.AesOfb256,
+		},
+	}
+
+	for _, tc := range tcs {
+		ki := new(KeyImportImpl[string])
+
+		_, err := ki.KeyImport("123456", tc.algorithm)
+		assert.Errorf(t, err, "KeyImport %s without WithUnauthenticated should fail", tc.algorithm)
+
+		k, err := ki.KeyImport("123456", tc.algorithm, WithUnauthenticated[string]())
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+		assert.Equal(t, tc.algorithm, k.Algorithm(), "Algorithm failed")
+
+		ct, err := k.Encrypt("hello world")
+		assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+
+		plaintext, err := k.Decrypt(ct)
+		assert.NoErrorf(t, err, "Decrypt failed: %s", err)
+		assert.Equal(t, "hello world", plaintext, "Decrypt failed")
+	}
+
+	_, err := new(KeyImportImpl[string]).KeyImport("123456", types.AesGcm256, WithUnauthenticated[string]())
+	assert.EqualError(t, err, "aes: invalid key type", "WithUnauthenticated on an authenticated key should fail")
+}
+
+func TestRawKeyInterop(t *testing.T) {
+	rawKey := bytes.Repeat([]byte{0x42}, 32)
+	nonce := bytes.Repeat([]byte{0x24}, 12)
+
+	block, err := aes.NewCipher(rawKey)
+	assert.NoErrorf(t, err, "NewCipher failed: %s", err)
+
+	gcm, err := cipher.NewGCM(block)
+	assert.NoErrorf(t, err, "NewGCM failed: %s", err)
+
+	sealed := gcm.Seal(bytes.Clone(nonce), nonce, []byte("hello world"), nil)
+
+	ki := new(KeyImportImpl[[]byte])
+
+	k, err := ki.KeyImport(rawKey, types.AesGcm256, WithRawKey[[]byte]())
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	plaintext, err := k.Decrypt([]byte(types.AesGcm256 + "." + base64.RawStdEncoding.EncodeToString(sealed)))
+	assert.NoErrorf(t, err, "Decrypt failed: %s", err)
+	assert.Equal(t, []byte("hello world"), plaintext, "Decrypt failed")
+
+	k, err = ki.KeyImport(rawKey, types.AesGcm256)
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	_, err = k.Decrypt([]byte(types.AesGcm256 + "." + base64.RawStdEncoding.EncodeToString(sealed)))
+	assert.Error(t, err, "Decrypt with derived key should fail")
+
+	_, err = ki.KeyImport(rawKey, types.AesGcm128, WithRawKey[[]byte]())
+	assert.Error(t, err, "KeyImport with wrong raw key length should fail")
+
+	for _, alg := range []types.Algorithm{types.AesCbc256, types.AesCbcHmac256, types.AesGcmSiv256, types.AesSiv256} {
+		k, err = ki.KeyImport(rawKey, alg, WithRawKey[[]byte]())
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		ct, err := k.Encrypt([]byte("hello world"))
+		assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+
+		plaintext, err = k.Decrypt(ct)
+		assert.NoErrorf(t, err, "Decrypt failed: %s", err)
+		assert.Equal(t, []byte("hello world"), plaintext, "Decrypt failed")
+
+		// the subkeys are derived from the raw key, not from the extended key
+		if ck, ok := k.(*CbcHmacKeyImpl[[]byte]); ok {
+			extended, err := ki.KeyImport(rawKey, alg)
+			assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+			assert.NotEqual(t, extended.(*CbcHmacKeyImpl[[]byte]).encKey, ck.encKey, "encryption key should depend on WithRawKey")
+			assert.NotEqual(t, extended.(*CbcHmacKeyImpl[[]byte]).macKey, ck.macKey, "mac key should depend on WithRawKey")
+		}
+	}
+}
+
+func TestRawKeyLookalikes(t *testing.T) {
+	ki := new(KeyImportImpl[string])
+
+	for _, alg := range []types.Algorithm{
+		types.AesCbc128, types.AesGcm192, types.AesCbcHmac256, types.AesGcmSiv128, types.AesSiv384,
+		types.AesSiv512, types.AesCbcHmac192, types.AesKw256,
+	} {
+		size := keySize(alg)
+		rawKey := strings.Repeat("k", size)
+		encoded := base64.RawStdEncoding.EncodeToString([]byte(rawKey))
+
+		// hex keys of the length of the base64 encoding of a key decode to a key of the right size
+		lookalikes := []string{
+			hex.EncodeToString([]byte(rawKey)),
+			strings.Repeat("0f", len(encoded))[:len(encoded)],
+			encoded,
+			base64.StdEncoding.EncodeToString([]byte(rawKey)),
+		}
+
+		_, err := ki.KeyImport(rawKey, alg, WithRawKey[string]())
+		assert.NoErrorf(t, err, "KeyImport of %s failed: %s", alg, err)
+
+		for _, lookalike := range lookalikes {
+			_, err = ki.KeyImport(lookalike, alg, WithRawKey[string]())
+			assert.Errorf(t, err, "KeyImport of %s with raw key %q should fail", alg, lookalike)
+		}
+
+		k, err := ki.KeyImport(encoded, alg, WithBase64RawKey[string]())
+		assert.NoErrorf(t, err, "KeyImport of %s failed: %s", alg, err)
+
+		exported, err := k.Export()
+		assert.NoErrorf(t, err, "Export failed: %s", err)
+		assert.Equal(t, encoded, exported, "Export failed")
+
+		for _, invalid := range []string{
+			rawKey,
+			hex.EncodeToString([]byte(rawKey)),
+			base64.RawStdEncoding.EncodeToString([]byte(rawKey[1:])),
+			base64.RawStdEncoding.EncodeToString([]byte(rawKey + "k")),
+		} {
+			_, err = ki.KeyImport(invalid, alg, WithBase64RawKey[string]())
+			assert.Errorf(t, err, "KeyImport of %s with base64 raw key %q should fail", alg, invalid)
+		}
+	}
+}
+
+func TestPasswordEncryptAndDecrypt(t *testing.T) {
+	pbkdf2Key, err := new(pbkdf2.KeyGeneratorImpl[string]).KeyGen(types.Pbkdf2Sha256, pbkdf2.WithIterations[string](20000))
+	assert.NoErrorf(t, err, "KeyGen failed: %s", err)
+
+	tcs := []struct {
+		algorithm types.Algorithm
+		kdf       key.Key[string]
+		header    string
+	}{
+		{
+			algorithm: types.AesGcm256,
+			header:    types.AesGcm256 + ".argon2id$v=19$m=65536,t=1,p=4$",
+		},
+		{
+			algorithm: types.AesCbcHmac128,
+			kdf:       pbkdf2Key,
+			header:    types.AesCbcHmac128 + ".pbkdf2_sha256$20000$",
+		},
+	}
+
+	for _, tc := range tcs {
+		ki := new(KeyImportImpl[string])
+
+		k, err := ki.KeyImport("123456", tc.algorithm, WithPassword[string](tc.kdf))
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+		assert.Equal(t, tc.algorithm, k.Algorithm(), "Algorithm failed")
+
+		ct1, err := k.Encrypt("hello world")
+		assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+		assert.True(t, strings.HasPrefix(ct1, tc.header), "Encrypt failed: %s", ct1)
+
+		ct2, err := k.Encrypt("hello world")
+		assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+		assert.NotEqual(t, ct1, ct2, "Encrypt should use a random salt")
+
+		plaintext, err := k.Decrypt(ct1)
+		assert.NoErrorf(t, err, "Decrypt failed: %s", err)
+		assert.Equal(t, "hello world", plaintext, "Decrypt failed")
+
+		aead := k.(key.AEADKey[string])
+
+		ct, err := aead.EncryptWithAAD("hello world", "row-1")
+		assert.NoErrorf(t, err, "EncryptWithAAD failed: %s", err)
+
+		plaintext, err = aead.DecryptWithAAD(ct, "row-1")
+		assert.NoErrorf(t, err, "DecryptWithAAD failed: %s", err)
+		assert.Equal(t, "hello world", plaintext, "DecryptWithAAD failed")
+
+		other, err := ki.KeyImport("654321", tc.algorithm, WithPassword[string](tc.kdf))
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		_, err = other.Decrypt(ct1)
+		assert.Error(t, err, "Decrypt with wrong password should fail")
+	}
+
+	_, err = new(KeyImportImpl[string]).KeyImport("123456", types.AesGcm256, WithPassword[string](new(GcmKeyImpl[string])))
+	assert.Error(t, err, "WithPassword with a non-kdf key should fail")
+}
+
+func TestKeyWrap(t *testing.T) {
+	tcs := []struct {
+		algorithm types.Algorithm
+		keyData   []byte
+		wantErr   bool
+	}{
+		{
+			algorithm: types.AesKw128,
+			keyData:   bytes.Repeat([]byte{0x01}, 16),
+		},
+		{
+			algorithm: types.AesKw192,
+			keyData:   bytes.Repeat([]byte{0x01}, 24),
+		},
+		{
+			algorithm: types.AesKw256,
+			keyData:   bytes.Repeat([]byte{0x01}, 32),
+		},
+		{
+			algorithm: types.AesKw256,
+			keyData:   []byte("hello world"),
+			wantErr:   true,
+		},
+		{
+			algorithm: types.AesKwp128,
+			keyData:   []byte("hello world"),
+		},
+		{
+			algorithm: types.AesKwp192,
+			keyData:   []byte("hello"),
+		},
+		{
+			algorithm: types.AesKwp256,
+			keyData:   bytes.Repeat([]byte{0x01}, 32),
+		},
+	}
+
+	for _, tc := range tcs {
+		k, err := new(KeyImportImpl[[]byte]).KeyImport("123456", tc.algorithm)
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		wrapped, err := k.Encrypt(tc.keyData)
+		if tc.wantErr {
+			assert.Error(t, err, "Encrypt should fail")
+			continue
+		}
+		assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+
+		again, err := k.Encrypt(tc.keyData)
+		assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+		assert.Equal(t, wrapped, again, "Key wrap should be deterministic")
+
+		keyData, err := k.Decrypt(wrapped)
+		assert.NoErrorf(t, err, "Decrypt failed: %s", err)
+		assert.Equal(t, tc.keyData, keyData, "Decrypt failed")
+
+		wrapped[len(wrapped)-2]++
+		_, err = k.Decrypt(wrapped)
+		assert.Error(t, err, "Decrypt tampered ciphertext should fail")
+	}
+}
+
+func TestStreamingEncryptAndDecrypt(t *testing.T) {
+	for _, alg := range []types.Algorithm{types.AesGcm128, types.AesGcm192, types.AesGcm256} {
+		k, err := new(KeyImportImpl[string]).KeyImport("123456", alg)
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		sk, ok := k.(key.StreamingKey)
+		assert.True(t, ok, "key does not implement StreamingKey")
+
+		plaintext := bytes.Repeat([]byte("hello world"), 10000)
+		ciphertext := bytes.NewBuffer(nil)
+
+		w, err := sk.NewEncryptingWriter(ciphertext)
+		assert.NoErrorf(t, err, "NewEncryptingWriter failed: %s", err)
+
+		_, err = w.Write(plaintext)
+		assert.NoErrorf(t, err, "Write failed: %s", err)
+		assert.NoError(t, w.Close(), "Close failed")
+
+		assert.True(t, strings.HasPrefix(ciphertext.String(), alg+"."), "stream header should name the algorithm")
+
+		r, err := sk.NewDecryptingReader(bytes.NewReader(ciphertext.Bytes()))
+		assert.NoErrorf(t, err, "NewDecryptingReader failed: %s", err)
+
+		decrypted, err := io.ReadAll(r)
+		assert.NoErrorf(t, err, "ReadAll failed: %s", err)
+		assert.Equal(t, plaintext, decrypted, "decrypt failed")
+
+		other, err := new(KeyImportImpl[string]).KeyImport("654321", alg)
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		r, err = other.(key.StreamingKey).NewDecryptingReader(bytes.NewReader(ciphertext.Bytes()))
+		assert.NoErrorf(t, err, "NewDecryptingReader failed: %s", err)
+
+		_, err = io.ReadAll(r)
+		assert.Error(t, err, "decrypt with wrong key should fail")
+	}
+}
@@ -0,0 +1,187 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+const (
+	cbcHmacEncryptionKeyInfo = "dipper aes-cbc-hmac encryption key"
+	cbcHmacMacKeyInfo        = "dipper aes-cbc-hmac mac key"
+)
+
+// CbcHmacKeyImpl is an AES-CBC key authenticated with HMAC in the encrypt-then-MAC construction.
+// Separate encryption and MAC keys are derived from the imported key, and the tag covers the
+// associated data, the IV and the ciphertext. The tag is verified before the ciphertext is unpadded.
+type CbcHmacKeyImpl[T types.DataType] struct {
+	keyMaterial
+	encKey  []byte
+	macKey  []byte
+	macFunc func() hash.Hash
+}
+
+func (a *CbcHmacKeyImpl[T]) Algorithm() types.Algorithm {
+	return a.algorithm
+}
+
+func (a *CbcHmacKeyImpl[T]) Export() (key T, err error) {
+	return T(a.export()), nil
+}
+
+func (a *CbcHmacKeyImpl[T]) SKI() T {
+	sha := sha256.New()
+	sha.Write(a.inputKey)
+
+	return T(utils.ToHexString(sha.Sum(nil)))
+}
+
+func (a *CbcHmacKeyImpl[T]) PublicKey() (key.Key[T], error) {
+	return nil, ErrUnsupportedMethod
+}
+
+func (a *CbcHmacKeyImpl[T]) Sign(_ T) (T, error) {
+	return T(""), ErrUnsupportedMethod
+}
+
+func (a *CbcHmacKeyImpl[T]) Verify(_, _ T) (bool, error) {
+	return false, ErrUnsupportedMethod
+}
+
+func (a *CbcHmacKeyImpl[T]) Encrypt(plaintext T) (T, error) {
+	return a.EncryptWithAAD(plaintext, T(""))
+}
+
+func (a *CbcHmacKeyImpl[T]) EncryptWithAAD(plaintext, additionalData T) (T, error) {
+	paddedText := utils.Pkcs7Padding[T](plaintext, aes.BlockSize)
+
+	iv, err := utils.RandomSize(aes.BlockSize)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-cbc-hmac: encrypt failed to generate random iv: %w", err)
+	}
+
+	block, err := aes.NewCipher(a.encKey)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-cbc-hmac: encrypt failed to create aes cipher: %w", err)
+	}
+
+	mode := cipher.NewCBCEncrypter(block, iv)
+	dst := make([]byte, len(paddedText))
+	mode.CryptBlocks(dst, paddedText)
+
+	tag := a.tag(utils.ToBytes(additionalData), iv, dst)
+
+	payload := make([]byte, 0, len(iv)+len(dst)+len(tag))
+	payload = append(payload, iv...)
+	payload = append(payload, dst...)
+	payload = append(payload, tag...)
+
+	data := bytes.NewBuffer(nil)
+	data.WriteString(a.algorithm)
+	data.WriteString(".")
+	data.WriteString(base64.RawStdEncoding.EncodeToString(payload))
+
+	return T(data.Bytes()), nil
+}
+
+func (a *CbcHmacKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
+	return a.DecryptWithAAD(ciphertext, T(""))
+}
+
+func (a *CbcHmacKeyImpl[T]) DecryptWithAAD(ciphertext, additionalData T) (T, error) {
+	dataBytes := utils.ToString(ciphertext)
+	parts := strings.SplitN(dataBytes, ".", 2)
+	if len(parts) != 2 {
+		return T(""), errors.New("aes-cbc-hmac: invalid encrypted data structure")
+	}
+
+	algorithm, payload := parts[0], parts[1]
+
+	if algorithm != a.algorithm {
+		return T(""), fmt.Errorf("aes-cbc-hmac: invalid algorithm type: %s", algorithm)
+	}
+
+	encryptedPayload, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-cbc-hmac: decrypt failed to decode base64: %w", err)
+	}
+
+	tagSize := a.macFunc().Size()
+	if len(encryptedPayload) < aes.BlockSize+aes.BlockSize+tagSize {
+		return T(""), errors.New("aes-cbc-hmac: ciphertext too short")
+	}
+
+	iv := encryptedPayload[:aes.BlockSize]
+	ciphertextBytes := encryptedPayload[aes.BlockSize : len(encryptedPayload)-tagSize]
+	providedTag := encryptedPayload[len(encryptedPayload)-tagSize:]
+
+	if !hmac.Equal(a.tag(utils.ToBytes(additionalData), iv, ciphertextBytes), providedTag) {
+		return T(""), errors.New("aes-cbc-hmac: message authentication failed")
+	}
+
+	if len(ciphertextBytes)%aes.BlockSize != 0 {
+		return T(""), errors.New("aes-cbc-hmac: ciphertext is not a multiple of the block size")
+	}
+
+	block, err := aes.NewCipher(a.encKey)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-cbc-hmac: cipher creation error: %w", err)
+	}
+
+	mode := cipher.NewCBCDecrypter(block, iv)
+	paddedText := make([]byte, len(ciphertextBytes))
+	mode.CryptBlocks(paddedText, ciphertextBytes)
+
+	plaintext, err := utils.Pkcs7UnPadding(T(paddedText), aes.BlockSize)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-cbc-hmac: decrypt failed to unpad plaintext: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// deriveKeys derives the separate encryption and MAC keys from the AES key. It is called once the
+// key options have been applied, since WithRawKey replaces the AES key.
+func (a *CbcHmacKeyImpl[T]) deriveKeys() error {
+	a.encKey = make([]byte, len(a.extendKey))
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, a.extendKey, []byte(cbcHmacEncryptionKeyInfo)), a.encKey); err != nil {
+		return fmt.Errorf("aes-cbc-hmac: failed to derive encryption key: %w", err)
+	}
+
+	a.macKey = make([]byte, a.macFunc().Size())
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, a.extendKey, []byte(cbcHmacMacKeyInfo)), a.macKey); err != nil {
+		return fmt.Errorf("aes-cbc-hmac: failed to derive mac key: %w", err)
+	}
+
+	return nil
+}
+
+// tag computes HMAC(AAD || IV || ciphertext || AL), where AL is the bit length of the
+// associated data as a 64-bit big-endian integer.
+func (a *CbcHmacKeyImpl[T]) tag(additionalData, iv, ciphertext []byte) []byte {
+	al := make([]byte, 8)
+	binary.BigEndian.PutUint64(al, uint64(len(additionalData))*8)
+
+	hc := hmac.New(a.macFunc, a.macKey)
+	hc.Write(additionalData)
+	hc.Write(iv)
+	hc.Write(ciphertext)
+	hc.Write(al)
+
+	return hc.Sum(nil)
+}
@@ -0,0 +1,325 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+const (
+	gcmSivNonceSize = 12
+	gcmSivTagSize   = 16
+	gcmSivMaxLength = 1 << 36
+)
+
+type GcmSivKeyImpl[T types.DataType] struct {
+	keyMaterial
+}
+
+func (a *GcmSivKeyImpl[T]) Algorithm() types.Algorithm {
+	return a.algorithm
+}
+
+func (a *GcmSivKeyImpl[T]) Export() (key T, err error) {
+	return T(a.export()), nil
+}
+
+func (a *GcmSivKeyImpl[T]) SKI() T {
+	sha := sha256.New()
+	sha.Write(a.inputKey)
+
+	return T(utils.ToHexString(sha.Sum(nil)))
+}
+
+func (a *GcmSivKeyImpl[T]) PublicKey() (key.Key[T], error) {
+	return nil, ErrUnsupportedMethod
+}
+
+func (a *GcmSivKeyImpl[T]) Sign(_ T) (T, error) {
+	return T(""), ErrUnsupportedMethod
+}
+
+func (a *GcmSivKeyImpl[T]) Verify(_, _ T) (bool, error) {
+	return false, ErrUnsupportedMethod
+}
+
+func (a *GcmSivKeyImpl[T]) Encrypt(plaintext T) (T, error) {
+	return a.EncryptWithAAD(plaintext, T(""))
+}
+
+func (a *GcmSivKeyImpl[T]) EncryptWithAAD(plaintext, additionalData T) (T, error) {
+	aead, err := newGcmSiv(a.extendKey)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-gcm-siv: new gcm-siv cipher error: %w", err)
+	}
+
+	nonce, err := utils.RandomSize(aead.NonceSize())
+	if err != nil {
+		return T(""), fmt.Errorf("aes-gcm-siv: failed to generate random nonce: %w", err)
+	}
+
+	sealedData := aead.Seal(nil, nonce, utils.ToBytes(plaintext), utils.ToBytes(additionalData))
+
+	payload := make([]byte, 0, len(nonce)+len(sealedData))
+	payload = append(payload, nonce...)
+	payload = append(payload, sealedData...)
+
+	data := bytes.NewBuffer(nil)
+	data.WriteString(a.algorithm)
+	data.WriteString(".")
+	data.WriteString(base64.RawStdEncoding.EncodeToString(payload))
+
+	return T(data.Bytes()), nil
+}
+
+func (a *GcmSivKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
+	return a.DecryptWithAAD(ciphertext, T(""))
+}
+
+func (a *GcmSivKeyImpl[T]) DecryptWithAAD(ciphertext, additionalData T) (T, error) {
+	dataBytes := utils.ToString(ciphertext)
+
+	parts := strings.SplitN(dataBytes, ".", 2)
+	if len(parts) != 2 {
+		return T(""), errors.New("aes-gcm-siv: invalid encrypted data structure")
+	}
+
+	algorithm, payload := parts[0], parts[1]
+
+	if algorithm != a.algorithm {
+		return T(""), fmt.Errorf("aes-gcm-siv: invalid algorithm type: %s", algorithm)
+	}
+
+	encryptedPayload, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-gcm-siv: decrypt failed to decode base64: %w", err)
+	}
+
+	aead, err := newGcmSiv(a.extendKey)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-gcm-siv: new gcm-siv cipher error: %w", err)
+	}
+
+	if len(encryptedPayload) < aead.NonceSize() {
+		return T(""), errors.New("aes-gcm-siv: ciphertext too short")
+	}
+
+	nonce, ciphertextBytes := encryptedPayload[:aead.NonceSize()], encryptedPayload[aead.NonceSize():]
+
+	decryptedData, err := aead.Open(nil, nonce, ciphertextBytes, utils.ToBytes(additionalData))
+	if err != nil {
+		return T(""), fmt.Errorf("aes-gcm-siv: failed to decrypt data: %w", err)
+	}
+
+	return T(decryptedData), nil
+}
+
+// gcmSiv implements cipher.AEAD for AES-GCM-SIV as specified in RFC 8452.
+type gcmSiv struct {
+	block   cipher.Block
+	keySize int
+}
+
+func newGcmSiv(key []byte) (cipher.AEAD, error) {
+	if len(key) != 16 && len(key) != 32 {
+		return nil, fmt.Errorf("invalid key size %d", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcmSiv{block: block, keySize: len(key)}, nil
+}
+
+func (g *gcmSiv) NonceSize() int {
+	return gcmSivNonceSize
+}
+
+func (g *gcmSiv) Overhead() int {
+	return gcmSivTagSize
+}
+
+func (g *gcmSiv) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmSivNonceSize {
+		panic("aes-gcm-siv: incorrect nonce length given to GCM-SIV")
+	}
+	if uint64(len(plaintext)) > gcmSivMaxLength || uint64(len(additionalData)) > gcmSivMaxLength {
+		panic("aes-gcm-siv: message too large for GCM-SIV")
+	}
+
+	authKey, encBlock := g.deriveKeys(nonce)
+	tag := g.tag(authKey, encBlock, nonce, plaintext, additionalData)
+
+	ret, out := sliceForAppend(dst, len(plaintext)+gcmSivTagSize)
+	gcmSivCtr(encBlock, tag, out[:len(plaintext)], plaintext)
+	copy(out[len(plaintext):], tag)
+
+	return ret
+}
+
+func (g *gcmSiv) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmSivNonceSize {
+		panic("aes-gcm-siv: incorrect nonce length given to GCM-SIV")
+	}
+	if len(ciphertext) < gcmSivTagSize || uint64(len(ciphertext)) > gcmSivMaxLength+gcmSivTagSize ||
+		uint64(len(additionalData)) > gcmSivMaxLength {
+		return nil, errors.New("message authentication failed")
+	}
+
+	tag := ciphertext[len(ciphertext)-gcmSivTagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-gcmSivTagSize]
+
+	authKey, encBlock := g.deriveKeys(nonce)
+
+	ret, out := sliceForAppend(dst, len(ciphertext))
+	gcmSivCtr(encBlock, tag, out, ciphertext)
+
+	expectedTag := g.tag(authKey, encBlock, nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(expectedTag, tag) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errors.New("message authentication failed")
+	}
+
+	return ret, nil
+}
+
+// deriveKeys derives the per-nonce message authentication key and message encryption key.
+func (g *gcmSiv) deriveKeys(nonce []byte) ([]byte, cipher.Block) {
+	blocks := 4
+	if g.keySize == 32 {
+		blocks = 6
+	}
+
+	var in, out [aes.BlockSize]byte
+	copy(in[4:], nonce)
+
+	derived := make([]byte, 0, blocks*8)
+	for i := 0; i < blocks; i++ {
+		binary.LittleEndian.PutUint32(in[:4], uint32(i))
+		g.block.Encrypt(out[:], in[:])
+		derived = append(derived, out[:8]...)
+	}
+
+	encBlock, err := aes.NewCipher(derived[16:])
+	if err != nil {
+		panic("aes-gcm-siv: failed to create message encryption cipher: " + err.Error())
+	}
+
+	return derived[:16], encBlock
+}
+
+func (g *gcmSiv) tag(authKey []byte, encBlock cipher.Block, nonce, plaintext, additionalData []byte) []byte {
+	var lengthBlock [aes.BlockSize]byte
+	binary.LittleEndian.PutUint64(lengthBlock[:8], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengthBlock[8:], uint64(len(plaintext))*8)
+
+	p := newPolyval(authKey)
+	p.updatePadded(additionalData)
+	p.updatePadded(plaintext)
+	p.updatePadded(lengthBlock[:])
+
+	s := p.sum()
+	for i := 0; i < gcmSivNonceSize; i++ {
+		s[i] ^= nonce[i]
+	}
+	s[15] &= 0x7f
+
+	tag := make([]byte, gcmSivTagSize)
+	encBlock.Encrypt(tag, s[:])
+
+	return tag
+}
+
+// gcmSivCtr XORs src with the AES-GCM-SIV keystream, which uses the tag with the most significant
+// bit set as the initial counter block and increments its first 32 bits as a little-endian integer.
+func gcmSivCtr(block cipher.Block, tag, dst, src []byte) {
+	var counter, keystream [aes.BlockSize]byte
+	copy(counter[:], tag)
+	counter[15] |= 0x80
+
+	for len(src) > 0 {
+		block.Encrypt(keystream[:], counter[:])
+		binary.LittleEndian.PutUint32(counter[:4], binary.LittleEndian.Uint32(counter[:4])+1)
+
+		n := subtle.XORBytes(dst, src, keystream[:])
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// polyval implements the POLYVAL universal hash function over GF(2^128) defined by the
+// polynomial x^128 + x^127 + x^126 + x^121 + 1, as specified in RFC 8452.
+type polyval struct {
+	h, s [2]uint64
+}
+
+func newPolyval(key []byte) *polyval {
+	return &polyval{h: [2]uint64{
+		binary.LittleEndian.Uint64(key[:8]),
+		binary.LittleEndian.Uint64(key[8:]),
+	}}
+}
+
+// updatePadded absorbs data, padding the final block with zeros.
+func (p *polyval) updatePadded(data []byte) {
+	var block [aes.BlockSize]byte
+	for len(data) > 0 {
+		n := copy(block[:], data)
+		for i := n; i < aes.BlockSize; i++ {
+			block[i] = 0
+		}
+		data = data[n:]
+
+		p.s[0] ^= binary.LittleEndian.Uint64(block[:8])
+		p.s[1] ^= binary.LittleEndian.Uint64(block[8:])
+		p.s = polyvalDot(p.s, p.h)
+	}
+}
+
+func (p *polyval) sum() [aes.BlockSize]byte {
+	var out [aes.BlockSize]byte
+	binary.LittleEndian.PutUint64(out[:8], p.s[0])
+	binary.LittleEndian.PutUint64(out[8:], p.s[1])
+	return out
+}
+
+// polyvalDot computes a * b * x^-128 in constant time.
+func polyvalDot(a, b [2]uint64) [2]uint64 {
+	var r [2]uint64
+	for i := 0; i < 128; i++ {
+		bit := (a[i/64] >> (i % 64)) & 1
+		mask := -bit
+		r[0] ^= b[0] & mask
+		r[1] ^= b[1] & mask
+
+		lsb := r[0] & 1
+		r[0] = r[0]>>1 | r[1]<<63
+		r[1] = r[1]>>1 ^ (0xe100000000000000 & -lsb)
+	}
+	return r
+}
+
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
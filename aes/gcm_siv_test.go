@@ -0,0 +1,133 @@
+package aes
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	assert.NoErrorf(t, err, "DecodeString failed: %s", err)
+	return b
+}
+
+func TestPolyval(t *testing.T) {
+	// RFC 8452, Appendix A
+	p := newPolyval(mustDecodeHex(t, "25629347589242761d31f826ba4b757b"))
+	p.updatePadded(mustDecodeHex(t, "4f4f95668c83dfb6401762bb2d01a262"))
+	p.updatePadded(mustDecodeHex(t, "d1a24ddd2721d006bbe45f20d3c9f362"))
+
+	sum := p.sum()
+	assert.Equal(t, "f7a3b47b846119fae5b7866cf5e5b77e", hex.EncodeToString(sum[:]), "POLYVAL failed")
+}
+
+func TestGcmSivVectors(t *testing.T) {
+	// RFC 8452, Appendix C.1 (AES-128) and C.2 (AES-256)
+	tcs := []struct {
+		key, nonce, plaintext, aad, result string
+	}{
+		{
+			key:    "01000000000000000000000000000000",
+			nonce:  "030000000000000000000000",
+			result: "dc20e2d83f25705bb49e439eca56de25",
+		},
+		{
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "0100000000000000",
+			result:    "b5d839330ac7b786578782fff6013b815b287c22493a364c",
+		},
+		{
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "0200000000000000",
+			aad:       "01",
+			result:    "1e6daba35669f4273b0a1a2560969cdf790d99759abd1508",
+		},
+		{
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "020000000000000000000000",
+			aad:       "01",
+			result:    "296c7889fd99f41917f4462008299c5102745aaa3a0c469fad9e075a",
+		},
+		{
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "0200000000000000000000000000000003000000000000000000000000000000",
+			aad:       "01",
+			result:    "620048ef3c1e73e57e02bb8562c416a319e73e4caac8e96a1ecb2933145a1d71e6af6a7f87287da059a71684ed3498e1",
+		},
+		{
+			key:       "01000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "020000000000000000000000000000000300000000000000000000000000000004000000000000000000000000000000",
+			aad:       "01",
+			result:    "50c8303ea93925d64090d07bd109dfd9515a5a33431019c17d93465999a8b0053201d723120a8562b838cdff25bf9d1e6a8cc3865f76897c2e4b245cf31c51f2",
+		},
+		{
+			key:    "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce:  "030000000000000000000000",
+			result: "07f5f4169bbf55a8400cd47ea6fd400f",
+		},
+		{
+			key:       "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "0100000000000000",
+			result:    "c2ef328e5c71c83b843122130f7364b761e0b97427e3df28",
+		},
+		{
+			key:       "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "0200000000000000",
+			aad:       "01",
+			result:    "1de22967237a813291213f267e3b452f02d01ae33e4ec854",
+		},
+		{
+			key:       "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "020000000000000000000000",
+			aad:       "01",
+			result:    "163d6f9cc1b346cd453a2e4cc1a4a19ae800941ccdc57cc8413c277f",
+		},
+		{
+			key:       "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "0200000000000000000000000000000003000000000000000000000000000000",
+			aad:       "01",
+			result:    "07dad364bfc2b9da89116d7bef6daaaf6f255510aa654f920ac81b94e8bad365aea1bad12702e1965604374aab96dbbc",
+		},
+		{
+			key:       "0100000000000000000000000000000000000000000000000000000000000000",
+			nonce:     "030000000000000000000000",
+			plaintext: "020000000000000000000000000000000300000000000000000000000000000004000000000000000000000000000000",
+			aad:       "01",
+			result:    "c67a1f0f567a5198aa1fcc8e3f21314336f7f51ca8b1af61feac35a86416fa47fbca3b5f749cdf564527f2314f42fe2503332742b228c647173616cfd44c54eb",
+		},
+	}
+
+	for _, tc := range tcs {
+		aead, err := newGcmSiv(mustDecodeHex(t, tc.key))
+		assert.NoErrorf(t, err, "newGcmSiv failed: %s", err)
+
+		nonce, plaintext, aad := mustDecodeHex(t, tc.nonce), mustDecodeHex(t, tc.plaintext), mustDecodeHex(t, tc.aad)
+
+		sealed := aead.Seal(nil, nonce, plaintext, aad)
+		assert.Equal(t, tc.result, hex.EncodeToString(sealed), "Seal failed")
+
+		opened, err := aead.Open(nil, nonce, sealed, aad)
+		assert.NoErrorf(t, err, "Open failed: %s", err)
+		assert.Equal(t, tc.plaintext, hex.EncodeToString(opened), "Open failed")
+
+		if len(aad) > 0 {
+			_, err = aead.Open(nil, nonce, sealed, nil)
+			assert.Error(t, err, "Open without the associated data should fail")
+		}
+
+		sealed[0] ^= 0x01
+		_, err = aead.Open(nil, nonce, sealed, aad)
+		assert.Error(t, err, "Open tampered ciphertext should fail")
+	}
+}
@@ -0,0 +1,246 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+var (
+	kwDefaultIV  = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+	kwpIVPrefix  = []byte{0xa6, 0x59, 0x59, 0xa6}
+	errKwUnwrap  = errors.New("integrity check failed")
+	errKwPayload = errors.New("invalid key data length")
+)
+
+// KwKeyImpl is an AES key-encryption key. Encrypt wraps the given key data with the AES Key Wrap
+// algorithm (RFC 3394), or with the AES Key Wrap with Padding algorithm (RFC 5649) for the
+// aes_kwp_* algorithms, and Decrypt unwraps it. Wrapping is deterministic.
+type KwKeyImpl[T types.DataType] struct {
+	keyMaterial
+}
+
+func (a *KwKeyImpl[T]) Algorithm() types.Algorithm {
+	return a.algorithm
+}
+
+func (a *KwKeyImpl[T]) Export() (key T, err error) {
+	return T(a.export()), nil
+}
+
+func (a *KwKeyImpl[T]) SKI() T {
+	sha := sha256.New()
+	sha.Write(a.inputKey)
+
+	return T(utils.ToHexString(sha.Sum(nil)))
+}
+
+func (a *KwKeyImpl[T]) PublicKey() (key.Key[T], error) {
+	return nil, ErrUnsupportedMethod
+}
+
+func (a *KwKeyImpl[T]) Sign(_ T) (T, error) {
+	return T(""), ErrUnsupportedMethod
+}
+
+func (a *KwKeyImpl[T]) Verify(_, _ T) (bool, error) {
+	return false, ErrUnsupportedMethod
+}
+
+func (a *KwKeyImpl[T]) Encrypt(plaintext T) (T, error) {
+	block, err := aes.NewCipher(a.extendKey)
+	if err != nil {
+		return T(""), fmt.Errorf("%s: encrypt failed to create aes cipher: %w", a.mode(), err)
+	}
+
+	var wrapped []byte
+	if a.padded() {
+		wrapped, err = kwpWrap(block, utils.ToBytes(plaintext))
+	} else {
+		wrapped, err = kwWrap(block, kwDefaultIV, utils.ToBytes(plaintext))
+	}
+	if err != nil {
+		return T(""), fmt.Errorf("%s: failed to wrap key: %w", a.mode(), err)
+	}
+
+	data := bytes.NewBuffer(nil)
+	data.WriteString(a.algorithm)
+	data.WriteString(".")
+	data.WriteString(base64.RawStdEncoding.EncodeToString(wrapped))
+
+	return T(data.Bytes()), nil
+}
+
+func (a *KwKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
+	dataBytes := utils.ToString(ciphertext)
+	parts := strings.SplitN(dataBytes, ".", 2)
+	if len(parts) != 2 {
+		return T(""), fmt.Errorf("%s: invalid encrypted data structure", a.mode())
+	}
+
+	algorithm, payload := parts[0], parts[1]
+
+	if algorithm != a.algorithm {
+		return T(""), fmt.Errorf("%s: invalid algorithm type: %s", a.mode(), algorithm)
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return T(""), fmt.Errorf("%s: decrypt failed to decode base64: %w", a.mode(), err)
+	}
+
+	block, err := aes.NewCipher(a.extendKey)
+	if err != nil {
+		return T(""), fmt.Errorf("%s: cipher creation error: %w", a.mode(), err)
+	}
+
+	var keyData []byte
+	if a.padded() {
+		keyData, err = kwpUnwrap(block, wrapped)
+	} else {
+		keyData, err = kwUnwrap(block, kwDefaultIV, wrapped)
+	}
+	if err != nil {
+		return T(""), fmt.Errorf("%s: failed to unwrap key: %w", a.mode(), err)
+	}
+
+	return T(keyData), nil
+}
+
+func (a *KwKeyImpl[T]) padded() bool {
+	return a.algorithm == types.AesKwp128 || a.algorithm == types.AesKwp192 || a.algorithm == types.AesKwp256
+}
+
+func (a *KwKeyImpl[T]) mode() string {
+	if a.padded() {
+		return "aes-kwp"
+	}
+	return "aes-kw"
+}
+
+// kwWrap implements the wrapping process of RFC 3394, section 2.2.1, with the given initial value.
+func kwWrap(block cipher.Block, iv, plaintext []byte) ([]byte, error) {
+	if len(plaintext) < 16 || len(plaintext)%8 != 0 {
+		return nil, errKwPayload
+	}
+
+	n := len(plaintext) / 8
+	out := make([]byte, 8+len(plaintext))
+	copy(out, iv)
+	copy(out[8:], plaintext)
+
+	var b [aes.BlockSize]byte
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b[:8], out[:8])
+			copy(b[8:], out[i*8:(i+1)*8])
+			block.Encrypt(b[:], b[:])
+
+			binary.BigEndian.PutUint64(out[:8], binary.BigEndian.Uint64(b[:8])^uint64(n*j+i))
+			copy(out[i*8:(i+1)*8], b[8:])
+		}
+	}
+
+	return out, nil
+}
+
+// kwUnwrap implements the unwrapping process of RFC 3394, section 2.2.2. If iv is nil, the
+// integrity check is skipped and the recovered initial value is returned in front of the key data.
+func kwUnwrap(block cipher.Block, iv, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 24 || len(ciphertext)%8 != 0 {
+		return nil, errKwPayload
+	}
+
+	n := len(ciphertext)/8 - 1
+	out := bytes.Clone(ciphertext)
+
+	var b [aes.BlockSize]byte
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(out[:8])^uint64(n*j+i))
+			copy(b[8:], out[i*8:(i+1)*8])
+			block.Decrypt(b[:], b[:])
+
+			copy(out[:8], b[:8])
+			copy(out[i*8:(i+1)*8], b[8:])
+		}
+	}
+
+	if iv == nil {
+		return out, nil
+	}
+
+	if subtle.ConstantTimeCompare(out[:8], iv) != 1 {
+		return nil, errKwUnwrap
+	}
+
+	return out[8:], nil
+}
+
+// kwpWrap implements the wrapping process of RFC 5649, section 4.1.
+func kwpWrap(block cipher.Block, plaintext []byte) ([]byte, error) {
+	if len(plaintext) == 0 || uint64(len(plaintext)) > 1<<32-1 {
+		return nil, errKwPayload
+	}
+
+	iv := make([]byte, 8)
+	copy(iv, kwpIVPrefix)
+	binary.BigEndian.PutUint32(iv[4:], uint32(len(plaintext)))
+
+	padded := make([]byte, (len(plaintext)+7)/8*8)
+	copy(padded, plaintext)
+
+	if len(padded) == 8 {
+		out := make([]byte, aes.BlockSize)
+		copy(out, iv)
+		copy(out[8:], padded)
+		block.Encrypt(out, out)
+		return out, nil
+	}
+
+	return kwWrap(block, iv, padded)
+}
+
+// kwpUnwrap implements the unwrapping process of RFC 5649, section 4.2.
+func kwpUnwrap(block cipher.Block, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < 16 || len(ciphertext)%8 != 0 {
+		return nil, errKwPayload
+	}
+
+	var out []byte
+	if len(ciphertext) == 16 {
+		out = make([]byte, aes.BlockSize)
+		block.Decrypt(out, ciphertext)
+	} else {
+		var err error
+		if out, err = kwUnwrap(block, nil, ciphertext); err != nil {
+			return nil, err
+		}
+	}
+
+	iv, padded := out[:8], out[8:]
+	mli := int(binary.BigEndian.Uint32(iv[4:]))
+
+	valid := subtle.ConstantTimeCompare(iv[:4], kwpIVPrefix)
+	valid &= subtle.ConstantTimeLessOrEq(len(padded)-7, mli) & subtle.ConstantTimeLessOrEq(mli, len(padded))
+	if valid != 1 {
+		return nil, errKwUnwrap
+	}
+
+	if subtle.ConstantTimeCompare(padded[mli:], make([]byte, len(padded)-mli)) != 1 {
+		return nil, errKwUnwrap
+	}
+
+	return padded[:mli], nil
+}
@@ -0,0 +1,81 @@
+package aes
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKwVectors(t *testing.T) {
+	tcs := []struct {
+		kek        string
+		keyData    string
+		ciphertext string
+	}{
+		{
+			// RFC 3394, Section 4.1
+			kek:        "000102030405060708090a0b0c0d0e0f",
+			keyData:    "00112233445566778899aabbccddeeff",
+			ciphertext: "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5",
+		},
+		{
+			// RFC 3394, Section 4.6
+			kek:        "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+			keyData:    "00112233445566778899aabbccddeeff000102030405060708090a0b0c0d0e0f",
+			ciphertext: "28c9f404c4b810f4cbccb35cfb87f8263f5786e2d80ed326cbc7f0e71a99f43bfb988b9b7a02dd21",
+		},
+	}
+
+	for _, tc := range tcs {
+		block, err := aes.NewCipher(mustDecodeHex(t, tc.kek))
+		assert.NoErrorf(t, err, "NewCipher failed: %s", err)
+
+		wrapped, err := kwWrap(block, kwDefaultIV, mustDecodeHex(t, tc.keyData))
+		assert.NoErrorf(t, err, "Wrap failed: %s", err)
+		assert.Equal(t, tc.ciphertext, hex.EncodeToString(wrapped), "Wrap failed")
+
+		unwrapped, err := kwUnwrap(block, kwDefaultIV, wrapped)
+		assert.NoErrorf(t, err, "Unwrap failed: %s", err)
+		assert.Equal(t, tc.keyData, hex.EncodeToString(unwrapped), "Unwrap failed")
+
+		wrapped[0] ^= 0x01
+		_, err = kwUnwrap(block, kwDefaultIV, wrapped)
+		assert.Error(t, err, "Unwrap tampered ciphertext should fail")
+	}
+}
+
+func TestKwpVectors(t *testing.T) {
+	// RFC 5649, Section 6
+	block, err := aes.NewCipher(mustDecodeHex(t, "5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8"))
+	assert.NoErrorf(t, err, "NewCipher failed: %s", err)
+
+	tcs := []struct {
+		keyData    string
+		ciphertext string
+	}{
+		{
+			keyData:    "c37b7e6492584340bed12207808941155068f738",
+			ciphertext: "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a",
+		},
+		{
+			keyData:    "466f7250617369",
+			ciphertext: "afbeb0f07dfbf5419200f2ccb50bb24f",
+		},
+	}
+
+	for _, tc := range tcs {
+		wrapped, err := kwpWrap(block, mustDecodeHex(t, tc.keyData))
+		assert.NoErrorf(t, err, "Wrap failed: %s", err)
+		assert.Equal(t, tc.ciphertext, hex.EncodeToString(wrapped), "Wrap failed")
+
+		unwrapped, err := kwpUnwrap(block, wrapped)
+		assert.NoErrorf(t, err, "Unwrap failed: %s", err)
+		assert.Equal(t, tc.keyData, hex.EncodeToString(unwrapped), "Unwrap failed")
+
+		wrapped[len(wrapped)-1] ^= 0x01
+		_, err = kwpUnwrap(block, wrapped)
+		assert.Error(t, err, "Unwrap tampered ciphertext should fail")
+	}
+}
@@ -0,0 +1,54 @@
+package aes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yakumioto/dipper/internal/password"
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+)
+
+// WithPassword treats the imported key as a password. Every encryption derives a fresh AES key
+// from the password with a random salt using kdf, and stores the salt and the KDF parameters in
+// the ciphertext header so that Decrypt can derive the key again.
+//
+// kdf must be an Argon2 or PBKDF2 key created with KeyGenerate. If kdf is nil, Argon2id with the
+// default parameters of the argon2 package is used.
+func WithPassword[T types.DataType](kdf key.Key[T]) key.Option[T] {
+	return func(k key.Key[T]) error {
+		if mk, ok := k.(materialKey); ok {
+			deriver, err := password.Deriver(kdf)
+			if err != nil {
+				return fmt.Errorf("aes: %w", err)
+			}
+
+			mk.material().deriver = deriver
+			return nil
+		}
+		return errors.New("aes: invalid key type")
+	}
+}
+
+// PasswordKeyImpl is an AES key derived from a password for every encryption.
+// The ciphertext format is {algorithm}.{kdf header}${base64 payload}, where the payload is the
+// same as that of the underlying AES mode.
+type PasswordKeyImpl[T types.DataType] struct {
+	*password.Key[T]
+}
+
+func newPasswordKeyImpl[T types.DataType](alg types.Algorithm, pass []byte, keyLen int, deriver key.Deriver) *PasswordKeyImpl[T] {
+	cipher := password.Cipher[T]{
+		Name:    "aes",
+		KeySize: keyLen,
+		NewKey: func(derivedKey []byte) (key.Key[T], error) {
+			k := newKeyImpl[T](keyMaterial{algorithm: alg, inputKey: pass, extendKey: derivedKey})
+			if err := deriveSubkeys(k); err != nil {
+				return nil, err
+			}
+			return k, nil
+		},
+		ErrUnsupportedMethod: ErrUnsupportedMethod,
+	}
+	return &PasswordKeyImpl[T]{password.New(cipher, alg, pass, deriver)}
+}
@@ -0,0 +1,235 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+// SivKeyImpl is a deterministic AES-SIV key as specified in RFC 5297. The same plaintext and
+// associated data always produce the same ciphertext, which allows encrypted values to be compared
+// for equality. The first half of the key is used for S2V and the second half for CTR encryption.
+type SivKeyImpl[T types.DataType] struct {
+	keyMaterial
+}
+
+func (a *SivKeyImpl[T]) Algorithm() types.Algorithm {
+	return a.algorithm
+}
+
+func (a *SivKeyImpl[T]) Export() (key T, err error) {
+	return T(a.export()), nil
+}
+
+func (a *SivKeyImpl[T]) SKI() T {
+	sha := sha256.New()
+	sha.Write(a.inputKey)
+
+	return T(utils.ToHexString(sha.Sum(nil)))
+}
+
+func (a *SivKeyImpl[T]) PublicKey() (key.Key[T], error) {
+	return nil, ErrUnsupportedMethod
+}
+
+func (a *SivKeyImpl[T]) Sign(_ T) (T, error) {
+	return T(""), ErrUnsupportedMethod
+}
+
+func (a *SivKeyImpl[T]) Verify(_, _ T) (bool, error) {
+	return false, ErrUnsupportedMethod
+}
+
+func (a *SivKeyImpl[T]) Encrypt(plaintext T) (T, error) {
+	return a.EncryptWithAAD(plaintext, T(""))
+}
+
+func (a *SivKeyImpl[T]) EncryptWithAAD(plaintext, additionalData T) (T, error) {
+	macBlock, ctrBlock, err := a.ciphers()
+	if err != nil {
+		return T(""), fmt.Errorf("aes-siv: new aes cipher error: %w", err)
+	}
+
+	payload := sivSeal(macBlock, ctrBlock, utils.ToBytes(plaintext), sivAssociatedData(utils.ToBytes(additionalData))...)
+
+	data := bytes.NewBuffer(nil)
+	data.WriteString(a.algorithm)
+	data.WriteString(".")
+	data.WriteString(base64.RawStdEncoding.EncodeToString(payload))
+
+	return T(data.Bytes()), nil
+}
+
+func (a *SivKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
+	return a.DecryptWithAAD(ciphertext, T(""))
+}
+
+func (a *SivKeyImpl[T]) DecryptWithAAD(ciphertext, additionalData T) (T, error) {
+	dataBytes := utils.ToString(ciphertext)
+
+	parts := strings.SplitN(dataBytes, ".", 2)
+	if len(parts) != 2 {
+		return T(""), errors.New("aes-siv: invalid encrypted data structure")
+	}
+
+	algorithm, payload := parts[0], parts[1]
+
+	if algorithm != a.algorithm {
+		return T(""), fmt.Errorf("aes-siv: invalid algorithm type: %s", algorithm)
+	}
+
+	encryptedPayload, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-siv: decrypt failed to decode base64: %w", err)
+	}
+
+	if len(encryptedPayload) < aes.BlockSize {
+		return T(""), errors.New("aes-siv: ciphertext too short")
+	}
+
+	macBlock, ctrBlock, err := a.ciphers()
+	if err != nil {
+		return T(""), fmt.Errorf("aes-siv: new aes cipher error: %w", err)
+	}
+
+	decryptedData, err := sivOpen(macBlock, ctrBlock, encryptedPayload, sivAssociatedData(utils.ToBytes(additionalData))...)
+	if err != nil {
+		return T(""), fmt.Errorf("aes-siv: failed to decrypt data: %w", err)
+	}
+
+	return T(decryptedData), nil
+}
+
+func (a *SivKeyImpl[T]) ciphers() (cipher.Block, cipher.Block, error) {
+	half := len(a.extendKey) / 2
+
+	macBlock, err := aes.NewCipher(a.extendKey[:half])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctrBlock, err := aes.NewCipher(a.extendKey[half:])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return macBlock, ctrBlock, nil
+}
+
+// sivAssociatedData maps empty associated data to no associated data components, so that
+// Encrypt and EncryptWithAAD with empty associated data produce the same ciphertext.
+func sivAssociatedData(additionalData []byte) [][]byte {
+	if len(additionalData) == 0 {
+		return nil
+	}
+	return [][]byte{additionalData}
+}
+
+// sivSeal returns the synthetic IV followed by the ciphertext.
+func sivSeal(macBlock, ctrBlock cipher.Block, plaintext []byte, additionalData ...[]byte) []byte {
+	v := s2v(macBlock, append(additionalData, plaintext)...)
+
+	out := make([]byte, aes.BlockSize+len(plaintext))
+	copy(out, v)
+	cipher.NewCTR(ctrBlock, sivCounter(v)).XORKeyStream(out[aes.BlockSize:], plaintext)
+
+	return out
+}
+
+func sivOpen(macBlock, ctrBlock cipher.Block, ciphertext []byte, additionalData ...[]byte) ([]byte, error) {
+	v, ciphertext := ciphertext[:aes.BlockSize], ciphertext[aes.BlockSize:]
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCTR(ctrBlock, sivCounter(v)).XORKeyStream(plaintext, ciphertext)
+
+	if subtle.ConstantTimeCompare(s2v(macBlock, append(additionalData, plaintext)...), v) != 1 {
+		return nil, errors.New("message authentication failed")
+	}
+
+	return plaintext, nil
+}
+
+// sivCounter clears the 31st and 63rd bits (counting from the right) of the synthetic IV.
+func sivCounter(v []byte) []byte {
+	q := bytes.Clone(v)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+	return q
+}
+
+// s2v implements the S2V pseudo-random function. The last component is the plaintext.
+func s2v(block cipher.Block, components ...[]byte) []byte {
+	d := cmac(block, make([]byte, aes.BlockSize))
+	for _, s := range components[:len(components)-1] {
+		d = dbl(d)
+		subtle.XORBytes(d, d, cmac(block, s))
+	}
+
+	last := components[len(components)-1]
+	var t []byte
+	if len(last) >= aes.BlockSize {
+		t = bytes.Clone(last)
+		subtle.XORBytes(t[len(t)-aes.BlockSize:], t[len(t)-aes.BlockSize:], d)
+	} else {
+		t = dbl(d)
+		padded := make([]byte, aes.BlockSize)
+		copy(padded, last)
+		padded[len(last)] = 0x80
+		subtle.XORBytes(t, t, padded)
+	}
+
+	return cmac(block, t)
+}
+
+// cmac implements AES-CMAC as specified in RFC 4493.
+func cmac(block cipher.Block, msg []byte) []byte {
+	l := make([]byte, aes.BlockSize)
+	block.Encrypt(l, l)
+	k1 := dbl(l)
+	k2 := dbl(k1)
+
+	n := (len(msg) + aes.BlockSize - 1) / aes.BlockSize
+	last := make([]byte, aes.BlockSize)
+	if n > 0 && len(msg)%aes.BlockSize == 0 {
+		subtle.XORBytes(last, msg[(n-1)*aes.BlockSize:], k1)
+	} else {
+		if n == 0 {
+			n = 1
+		}
+		rest := msg[(n-1)*aes.BlockSize:]
+		copy(last, rest)
+		last[len(rest)] = 0x80
+		subtle.XORBytes(last, last, k2)
+	}
+
+	x := make([]byte, aes.BlockSize)
+	for i := 0; i < n-1; i++ {
+		subtle.XORBytes(x, x, msg[i*aes.BlockSize:(i+1)*aes.BlockSize])
+		block.Encrypt(x, x)
+	}
+	subtle.XORBytes(x, x, last)
+	block.Encrypt(x, x)
+
+	return x
+}
+
+// dbl multiplies a 128-bit block by x in GF(2^128) with the polynomial x^128 + x^7 + x^2 + x + 1.
+func dbl(in []byte) []byte {
+	out := make([]byte, aes.BlockSize)
+	carry := in[0] >> 7
+	for i := 0; i < aes.BlockSize-1; i++ {
+		out[i] = in[i]<<1 | in[i+1]>>7
+	}
+	out[aes.BlockSize-1] = in[aes.BlockSize-1]<<1 ^ byte(subtle.ConstantTimeByteEq(carry, 1))*0x87
+	return out
+}
@@ -0,0 +1,43 @@
+package aes
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCmac(t *testing.T) {
+	// RFC 4493, Section 4
+	block, err := aes.NewCipher(mustDecodeHex(t, "2b7e151628aed2a6abf7158809cf4f3c"))
+	assert.NoErrorf(t, err, "NewCipher failed: %s", err)
+
+	assert.Equal(t, "bb1d6929e95937287fa37d129b756746", hex.EncodeToString(cmac(block, nil)), "CMAC failed")
+	assert.Equal(t, "070a16b46b4d4144f79bdd9dd04a287c",
+		hex.EncodeToString(cmac(block, mustDecodeHex(t, "6bc1bee22e409f96e93d7e117393172a"))), "CMAC failed")
+}
+
+func TestSivVectors(t *testing.T) {
+	// RFC 5297, Appendix A.1
+	key := mustDecodeHex(t, "fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	ad := mustDecodeHex(t, "101112131415161718191a1b1c1d1e1f2021222324252627")
+	plaintext := mustDecodeHex(t, "112233445566778899aabbccddee")
+
+	macBlock, err := aes.NewCipher(key[:16])
+	assert.NoErrorf(t, err, "NewCipher failed: %s", err)
+
+	ctrBlock, err := aes.NewCipher(key[16:])
+	assert.NoErrorf(t, err, "NewCipher failed: %s", err)
+
+	sealed := sivSeal(macBlock, ctrBlock, plaintext, ad)
+	assert.Equal(t, "85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c", hex.EncodeToString(sealed), "Seal failed")
+
+	opened, err := sivOpen(macBlock, ctrBlock, sealed, ad)
+	assert.NoErrorf(t, err, "Open failed: %s", err)
+	assert.Equal(t, plaintext, opened, "Open failed")
+
+	sealed[len(sealed)-1] ^= 0x01
+	_, err = sivOpen(macBlock, ctrBlock, sealed, ad)
+	assert.Error(t, err, "Open tampered ciphertext should fail")
+}
@@ -0,0 +1,147 @@
+package aes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+// WithUnauthenticated acknowledges that the AES-CTR, AES-CFB and AES-OFB modes do not authenticate
+// the ciphertext. Importing these algorithms fails without this option.
+func WithUnauthenticated[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		if a, ok := k.(*UnauthenticatedKeyImpl[T]); ok {
+			a.unauthenticated = true
+			return nil
+		}
+		return errors.New("aes: invalid key type")
+	}
+}
+
+// UnauthenticatedKeyImpl is an AES key used in one of the unauthenticated stream modes (CTR, CFB
+// or OFB). It exists for interoperability with legacy systems, and the ciphertext can be modified
+// without being detected.
+type UnauthenticatedKeyImpl[T types.DataType] struct {
+	keyMaterial
+	unauthenticated bool
+}
+
+func (a *UnauthenticatedKeyImpl[T]) Algorithm() types.Algorithm {
+	return a.algorithm
+}
+
+func (a *UnauthenticatedKeyImpl[T]) Export() (key T, err error) {
+	return T(a.export()), nil
+}
+
+func (a *UnauthenticatedKeyImpl[T]) SKI() T {
+	sha := sha256.New()
+	sha.Write(a.inputKey)
+
+	return T(utils.ToHexString(sha.Sum(nil)))
+}
+
+func (a *UnauthenticatedKeyImpl[T]) PublicKey() (key.Key[T], error) {
+	return nil, ErrUnsupportedMethod
+}
+
+func (a *UnauthenticatedKeyImpl[T]) Sign(_ T) (T, error) {
+	return T(""), ErrUnsupportedMethod
+}
+
+func (a *UnauthenticatedKeyImpl[T]) Verify(_, _ T) (bool, error) {
+	return false, ErrUnsupportedMethod
+}
+
+func (a *UnauthenticatedKeyImpl[T]) Encrypt(plaintext T) (T, error) {
+	iv, err := utils.RandomSize(aes.BlockSize)
+	if err != nil {
+		return T(""), fmt.Errorf("%s: encrypt failed to generate random iv: %w", a.mode().name, err)
+	}
+
+	block, err := aes.NewCipher(a.extendKey)
+	if err != nil {
+		return T(""), fmt.Errorf("%s: encrypt failed to create aes cipher: %w", a.mode().name, err)
+	}
+
+	stream := a.mode().encrypter(block, iv)
+
+	plaintextBytes := utils.ToBytes(plaintext)
+	dst := make([]byte, len(plaintextBytes))
+	stream.XORKeyStream(dst, plaintextBytes)
+
+	payload := make([]byte, 0, len(iv)+len(dst))
+	payload = append(payload, iv...)
+	payload = append(payload, dst...)
+
+	data := bytes.NewBuffer(nil)
+	data.WriteString(a.algorithm)
+	data.WriteString(".")
+	data.WriteString(base64.RawStdEncoding.EncodeToString(payload))
+
+	return T(data.Bytes()), nil
+}
+
+func (a *UnauthenticatedKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
+	dataBytes := utils.ToString(ciphertext)
+	parts := strings.SplitN(dataBytes, ".", 2)
+	if len(parts) != 2 {
+		return T(""), fmt.Errorf("%s: invalid encrypted data structure", a.mode().name)
+	}
+
+	algorithm, payload := parts[0], parts[1]
+
+	if algorithm != a.algorithm {
+		return T(""), fmt.Errorf("%s: invalid algorithm type: %s", a.mode().name, algorithm)
+	}
+
+	encryptedPayload, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return T(""), fmt.Errorf("%s: decrypt failed to decode base64: %w", a.mode().name, err)
+	}
+
+	if len(encryptedPayload) < aes.BlockSize {
+		return T(""), fmt.Errorf("%s: ciphertext too short", a.mode().name)
+	}
+
+	iv, ciphertextBytes := encryptedPayload[:aes.BlockSize], encryptedPayload[aes.BlockSize:]
+
+	block, err := aes.NewCipher(a.extendKey)
+	if err != nil {
+		return T(""), fmt.Errorf("%s: cipher creation error: %w", a.mode().name, err)
+	}
+
+	stream := a.mode().decrypter(block, iv)
+
+	plaintext := make([]byte, len(ciphertextBytes))
+	stream.XORKeyStream(plaintext, ciphertextBytes)
+
+	return T(plaintext), nil
+}
+
+// streamMode is an unauthenticated AES mode, with the name prefixing its errors.
+type streamMode struct {
+	name      string
+	encrypter func(block cipher.Block, iv []byte) cipher.Stream
+	decrypter func(block cipher.Block, iv []byte) cipher.Stream
+}
+
+func (a *UnauthenticatedKeyImpl[T]) mode() streamMode {
+	switch a.algorithm {
+	case types.AesCtr128, types.AesCtr192, types.AesCtr256:
+		return streamMode{name: "aes-ctr", encrypter: cipher.NewCTR, decrypter: cipher.NewCTR}
+	case types.AesCfb128, types.AesCfb192, types.AesCfb256:
+		return streamMode{name: "aes-cfb", encrypter: cipher.NewCFBEncrypter, decrypter: cipher.NewCFBDecrypter}
+	default:
+		return streamMode{name: "aes-ofb", encrypter: cipher.NewOFB, decrypter: cipher.NewOFB}
+	}
+}
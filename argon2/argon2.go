@@ -26,12 +26,12 @@ var (
 
 func WithMethod[T types.DataType](method string) key.Option[T] {
 	return func(k key.Key[T]) error {
-		if _, ok := k.(*KeyImpl[T]); ok {
+		if k, ok := k.(*KeyImpl[T]); ok {
 			if method != MethodArgon2i && method != MethodArgon2id {
 				return fmt.Errorf("argon2: invalid method: %s", method)
 			}
 
-			k.(*KeyImpl[T]).method = method
+			k.method = method
 			return nil
 		}
 		return errors.New("argon2: invalid key type")
@@ -40,12 +40,12 @@ func WithMethod[T types.DataType](method string) key.Option[T] {
 
 func WithSaltSize[T types.DataType](size int) key.Option[T] {
 	return func(k key.Key[T]) error {
-		if _, ok := k.(*KeyImpl[T]); ok {
+		if k, ok := k.(*KeyImpl[T]); ok {
 			if size <= 0 {
 				return nil
 			}
 
-			k.(*KeyImpl[T]).saltSize = size
+			k.saltSize = size
 			return nil
 		}
 		return errors.New("argon2: invalid key type")
@@ -54,11 +54,11 @@ func WithSaltSize[T types.DataType](size int) key.Option[T] {
 
 func WithTime[T types.DataType](time uint32) key.Option[T] {
 	return func(k key.Key[T]) error {
-		if _, ok := k.(*KeyImpl[T]); ok {
+		if k, ok := k.(*KeyImpl[T]); ok {
 			if time == 0 {
 				return nil
 			}
-			k.(*KeyImpl[T]).time = time
+			k.time = time
 			return nil
 		}
 		return errors.New("argon2: invalid key type")
@@ -67,11 +67,11 @@ func WithTime[T types.DataType](time uint32) key.Option[T] {
 
 func WithMemory[T types.DataType](memory uint32) key.Option[T] {
 	return func(k key.Key[T]) error {
-		if _, ok := k.(*KeyImpl[T]); ok {
+		if k, ok := k.(*KeyImpl[T]); ok {
 			if memory == 0 {
 				return nil
 			}
-			k.(*KeyImpl[T]).memory = memory
+			k.memory = memory
 			return nil
 		}
 		return errors.New("argon2: invalid key type")
@@ -80,11 +80,11 @@ func WithMemory[T types.DataType](memory uint32) key.Option[T] {
 
 func WithThreads[T types.DataType](threads uint8) key.Option[T] {
 	return func(k key.Key[T]) error {
-		if _, ok := k.(*KeyImpl[T]); ok {
+		if k, ok := k.(*KeyImpl[T]); ok {
 			if threads == 0 {
 				return nil
 			}
-			k.(*KeyImpl[T]).threads = threads
+			k.threads = threads
 			return nil
 		}
 		return errors.New("argon2: invalid key type")
@@ -93,11 +93,11 @@ func WithThreads[T types.DataType](threads uint8) key.Option[T] {
 
 func WithLength[T types.DataType](length uint32) key.Option[T] {
 	return func(k key.Key[T]) error {
-		if _, ok := k.(*KeyImpl[T]); ok {
+		if k, ok := k.(*KeyImpl[T]); ok {
 			if length <= 0 {
 				return nil
 			}
-			k.(*KeyImpl[T]).length = length
+			k.length = length
 			return nil
 		}
 		return errors.New("argon2: invalid key type")
@@ -222,6 +222,81 @@ func (k *KeyImpl[T]) Verify(msg, signature T) (bool, error) {
 	return hmac.Equal(providedDigest, computedDigest), nil
 }
 
+func (k *KeyImpl[T]) DeriveKey(password []byte, keyLen int) ([]byte, string, error) {
+	saltBytes, err := utils.RandomSize(k.saltSize)
+	if err != nil {
+		return nil, "", fmt.Errorf("argon2: failed to generate random salt: %w", err)
+	}
+
+	var derivedKey []byte
+	if k.method == MethodArgon2i {
+		derivedKey = argon2.Key(password, saltBytes, k.time, k.memory, k.threads, uint32(keyLen))
+	} else {
+		derivedKey = argon2.IDKey(password, saltBytes, k.time, k.memory, k.threads, uint32(keyLen))
+	}
+
+	header := fmt.Sprintf("%s$v=%d$m=%d,t=%d,p=%d$%s",
+		k.method,
+		argon2.Version,
+		k.memory,
+		k.time,
+		k.threads,
+		base64.RawStdEncoding.EncodeToString(saltBytes),
+	)
+
+	return derivedKey, header, nil
+}
+
+// DeriveKeyFromHeader derives the key again from a header produced by DeriveKey. The memory, time
+// and threads parameters in the header must not exceed those of k, so that a crafted header
+// cannot make the derivation arbitrarily expensive.
+func (k *KeyImpl[T]) DeriveKeyFromHeader(password []byte, header string, keyLen int) ([]byte, error) {
+	parts := strings.SplitN(header, "$", 4)
+	if len(parts) != 4 {
+		return nil, errors.New("argon2: invalid header data structure")
+	}
+
+	method, version, params, salt := parts[0], parts[1], parts[2], parts[3]
+	var (
+		v            int
+		memory, time uint32
+		threads      uint8
+		err          error
+	)
+
+	if method != MethodArgon2i && method != MethodArgon2id {
+		return nil, fmt.Errorf("argon2: invalid method: %s", method)
+	}
+
+	_, err = fmt.Sscanf(version, "v=%d", &v)
+	if err != nil {
+		return nil, fmt.Errorf("argon2: failed to parse version: %w", err)
+	}
+
+	if v != argon2.Version {
+		return nil, fmt.Errorf("argon2: invalid version: %d", v)
+	}
+
+	_, err = fmt.Sscanf(params, "m=%d,t=%d,p=%d", &memory, &time, &threads)
+	if err != nil {
+		return nil, fmt.Errorf("argon2: failed to parse params: %w", err)
+	}
+
+	if memory == 0 || time == 0 || threads == 0 || memory > k.memory || time > k.time || threads > k.threads {
+		return nil, fmt.Errorf("argon2: params out of range: m=%d,t=%d,p=%d", memory, time, threads)
+	}
+
+	saltBytes, err := base64.RawStdEncoding.DecodeString(salt)
+	if err != nil {
+		return nil, fmt.Errorf("argon2: failed to decode salt: %w", err)
+	}
+
+	if method == MethodArgon2i {
+		return argon2.Key(password, saltBytes, time, memory, threads, uint32(keyLen)), nil
+	}
+	return argon2.IDKey(password, saltBytes, time, memory, threads, uint32(keyLen)), nil
+}
+
 func (k *KeyImpl[T]) Encrypt(plaintext T) (ciphertext T, err error) {
 	return T(""), ErrUnsupportedMethod
 }
@@ -230,6 +305,11 @@ func (k *KeyImpl[T]) Decrypt(ciphertext T) (plaintext T, err error) {
 	return T(""), ErrUnsupportedMethod
 }
 
+func init() {
+	key.Register[string](types.Argon2, nil, new(KeyGeneratorImpl[string]))
+	key.Register[[]byte](types.Argon2, nil, new(KeyGeneratorImpl[[]byte]))
+}
+
 type KeyGeneratorImpl[T types.DataType] struct{}
 
 func (k *KeyGeneratorImpl[T]) KeyGen(alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/yakumioto/dipper/key"
 	"github.com/yakumioto/dipper/types"
 )
 
@@ -145,3 +146,31 @@ func TestSignAndVerify(t *testing.T) {
 		assert.True(t, result, "Verify failed")
 	}
 }
+
+func TestDeriveKey(t *testing.T) {
+	kg := new(KeyGeneratorImpl[string])
+
+	k, err := kg.KeyGen(types.Argon2, WithMemory[string](8*1024))
+	assert.NoErrorf(t, err, "KeyGen failed: %s", err)
+
+	deriver := k.(key.Deriver)
+
+	derivedKey, header, err := deriver.DeriveKey([]byte("123456"), 32)
+	assert.NoErrorf(t, err, "DeriveKey failed: %s", err)
+	assert.Len(t, derivedKey, 32, "DeriveKey failed")
+	assert.Contains(t, header, "argon2id$v=19$m=8192,t=1,p=4$", "DeriveKey failed")
+
+	rederivedKey, err := deriver.DeriveKeyFromHeader([]byte("123456"), header, 32)
+	assert.NoErrorf(t, err, "DeriveKeyFromHeader failed: %s", err)
+	assert.Equal(t, derivedKey, rederivedKey, "DeriveKeyFromHeader failed")
+
+	otherKey, err := deriver.DeriveKeyFromHeader([]byte("654321"), header, 32)
+	assert.NoErrorf(t, err, "DeriveKeyFromHeader failed: %s", err)
+	assert.NotEqual(t, derivedKey, otherKey, "DeriveKeyFromHeader failed")
+
+	_, err = deriver.DeriveKeyFromHeader([]byte("123456"), "argon2id$v=19$m=4194304,t=1,p=4$c2FsdA", 32)
+	assert.Error(t, err, "DeriveKeyFromHeader with expensive params should fail")
+
+	_, err = deriver.DeriveKeyFromHeader([]byte("123456"), "argon2id$v=19$m=8192,t=1", 32)
+	assert.Error(t, err, "DeriveKeyFromHeader with invalid header should fail")
+}
@@ -19,11 +19,81 @@ var (
 	ErrUnsupportedMethod = errors.New("chacha20: unsupported method")
 )
 
-type KeyImpl[T types.DataType] struct {
+// WithRawKey uses the imported key bytes as the ChaCha20 key as-is, instead of deriving the key
+// with utils.ExtendKey, so that ciphertexts interoperate with other implementations.
+// The key must be exactly chacha20.KeySize bytes. Use WithBase64RawKey to import the base64 keys
+// returned by Export.
+func WithRawKey[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		if mk, ok := k.(materialKey); ok {
+			return mk.material().setRawKey(mk.material().inputKey)
+		}
+		return errors.New("chacha20: invalid key type")
+	}
+}
+
+// WithBase64RawKey is like WithRawKey, but the imported key is the unpadded base64 encoding of the
+// ChaCha20 key, as returned by Export for raw and generated keys. It does nothing on keys that are
+// already raw, such as generated keys.
+func WithBase64RawKey[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		if mk, ok := k.(materialKey); ok {
+			m := mk.material()
+			if m.raw {
+				return nil
+			}
+
+			rawKey, err := base64.RawStdEncoding.DecodeString(string(m.inputKey))
+			if err != nil {
+				return fmt.Errorf("chacha20: invalid base64 raw key: %w", err)
+			}
+			return m.setRawKey(rawKey)
+		}
+		return errors.New("chacha20: invalid key type")
+	}
+}
+
+type keyMaterial struct {
 	inputKey  []byte
 	expendKey []byte
+	raw       bool
 	nonceSize int
 	algorithm types.Algorithm
+	deriver   key.Deriver
+}
+
+func (m *keyMaterial) material() *keyMaterial {
+	return m
+}
+
+// setRawKey uses rawKey as the ChaCha20 key, which must be exactly chacha20.KeySize bytes.
+func (m *keyMaterial) setRawKey(rawKey []byte) error {
+	if len(rawKey) != chacha20.KeySize {
+		return fmt.Errorf("chacha20: invalid raw key length: want %d bytes, got %d",
+			chacha20.KeySize, len(rawKey))
+	}
+
+	m.inputKey, m.expendKey, m.raw = rawKey, rawKey, true
+	return nil
+}
+
+// export returns the key returned by Export. Raw keys are binary and are encoded with unpadded
+// base64, which WithBase64RawKey accepts.
+func (m *keyMaterial) export() []byte {
+	if m.raw {
+		return []byte(base64.RawStdEncoding.EncodeToString(m.inputKey))
+	}
+	return m.inputKey
+}
+
+type materialKey interface {
+	material() *keyMaterial
+}
+
+// KeyImpl is a ChaCha20 or XChaCha20 key. The ciphertext is not authenticated, use the
+// chacha20_poly1305 or xchacha20_poly1305 algorithms unless compatibility requires otherwise.
+type KeyImpl[T types.DataType] struct {
+	keyMaterial
 }
 
 func (k *KeyImpl[T]) Algorithm() types.Algorithm {
@@ -31,7 +101,7 @@ func (k *KeyImpl[T]) Algorithm() types.Algorithm {
 }
 
 func (k *KeyImpl[T]) Export() (key T, err error) {
-	return T(k.inputKey), nil
+	return T(k.export()), nil
 }
 
 func (k *KeyImpl[T]) SKI() T {
@@ -115,6 +185,28 @@ func (k *KeyImpl[T]) Decrypt(ciphertext T) (plaintext T, err error) {
 	return T(plaintextBytes), nil
 }
 
+func init() {
+	for _, alg := range []types.Algorithm{
+		types.Chacha20, types.XChacha20, types.Chacha20Poly1305, types.XChacha20Poly1305,
+	} {
+		key.Register[string](alg, new(KeyImportImpl[string]), new(KeyGeneratorImpl[string]))
+		key.Register[[]byte](alg, new(KeyImportImpl[[]byte]), new(KeyGeneratorImpl[[]byte]))
+	}
+}
+
+// KeyGeneratorImpl generates random raw ChaCha20 keys. The generated key is exported as a base64
+// string and can be imported again with KeyImport and WithBase64RawKey.
+type KeyGeneratorImpl[T types.DataType] struct{}
+
+func (k *KeyGeneratorImpl[T]) KeyGen(alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
+	keyBytes, err := utils.RandomSize(chacha20.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("chacha20: failed to generate random key: %w", err)
+	}
+
+	return new(KeyImportImpl[T]).KeyImport(keyBytes, alg, append([]key.Option[T]{WithRawKey[T]()}, opts...)...)
+}
+
 type KeyImportImpl[T types.DataType] struct{}
 
 func (k *KeyImportImpl[T]) KeyImport(raw interface{}, alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
@@ -125,9 +217,9 @@ func (k *KeyImportImpl[T]) KeyImport(raw interface{}, alg types.Algorithm, opts
 
 	var nonceSize int
 	switch alg {
-	case types.Chacha20:
+	case types.Chacha20, types.Chacha20Poly1305:
 		nonceSize = chacha20.NonceSize
-	case types.XChacha20:
+	case types.XChacha20, types.XChacha20Poly1305:
 		nonceSize = chacha20.NonceSizeX
 	default:
 		return nil, fmt.Errorf("chacha20: invalid algorithm: %v", alg)
@@ -135,10 +227,31 @@ func (k *KeyImportImpl[T]) KeyImport(raw interface{}, alg types.Algorithm, opts
 
 	extendKey := utils.ExtendKey(keyBytes, chacha20.KeySize)
 
-	return &KeyImpl[T]{
+	ki := newKeyImpl[T](keyMaterial{
 		inputKey:  keyBytes,
 		expendKey: extendKey,
 		nonceSize: nonceSize,
 		algorithm: alg,
-	}, nil
+	})
+
+	for _, opt := range opts {
+		if err = opt(ki); err != nil {
+			return nil, err
+		}
+	}
+
+	if m := ki.(materialKey).material(); m.deriver != nil {
+		return newPasswordKeyImpl[T](alg, keyBytes, nonceSize, m.deriver), nil
+	}
+
+	return ki, nil
+}
+
+func newKeyImpl[T types.DataType](m keyMaterial) key.Key[T] {
+	switch m.algorithm {
+	case types.Chacha20Poly1305, types.XChacha20Poly1305:
+		return &Poly1305KeyImpl[T]{keyMaterial: m}
+	default:
+		return &KeyImpl[T]{keyMaterial: m}
+	}
 }
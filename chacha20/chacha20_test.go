@@ -1,10 +1,19 @@
 package chacha20
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/chacha20poly1305"
 
+	"github.com/yakumioto/dipper/argon2"
+	"github.com/yakumioto/dipper/key"
 	"github.com/yakumioto/dipper/types"
 )
 
@@ -63,6 +72,12 @@ func TestEncryptAndDecrypt(t *testing.T) {
 		{
 			algorithm: types.XChacha20,
 		},
+		{
+			algorithm: types.Chacha20Poly1305,
+		},
+		{
+			algorithm: types.XChacha20Poly1305,
+		},
 	}
 
 	for _, tc := range tcs {
@@ -81,3 +96,229 @@ func TestEncryptAndDecrypt(t *testing.T) {
 		assert.Equal(t, "hello world", plaintext, "Decrypt failed")
 	}
 }
+
+func TestRawKeyInterop(t *testing.T) {
+	rawKey := bytes.Repeat([]byte{0x42}, chacha20.KeySize)
+	nonce := bytes.Repeat([]byte{0x24}, chacha20.NonceSizeX)
+
+	c, err := chacha20.NewUnauthenticatedCipher(rawKey, nonce)
+	assert.NoErrorf(t, err, "NewUnauthenticatedCipher failed: %s", err)
+
+	ciphertext := make([]byte, len("hello world"))
+	c.XORKeyStream(ciphertext, []byte("hello world"))
+
+	ki := new(KeyImportImpl[string])
+
+	k, err := ki.KeyImport(rawKey, types.XChacha20, WithRawKey[string]())
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	plaintext, err := k.Decrypt(types.XChacha20 + "." + base64.RawStdEncoding.EncodeToString(append(nonce, ciphertext...)))
+	assert.NoErrorf(t, err, "Decrypt failed: %s", err)
+	assert.Equal(t, "hello world", plaintext, "Decrypt failed")
+
+	_, err = ki.KeyImport("123456", types.XChacha20, WithRawKey[string]())
+	assert.Error(t, err, "KeyImport with wrong raw key length should fail")
+}
+
+func TestRawKeyLookalikes(t *testing.T) {
+	ki := new(KeyImportImpl[string])
+	rawKey := strings.Repeat("k", chacha20.KeySize)
+	encoded := base64.RawStdEncoding.EncodeToString([]byte(rawKey))
+
+	for _, alg := range []types.Algorithm{types.Chacha20, types.XChacha20Poly1305} {
+		_, err := ki.KeyImport(rawKey, alg, WithRawKey[string]())
+		assert.NoErrorf(t, err, "KeyImport of %s failed: %s", alg, err)
+
+		// hex keys of the length of the base64 encoding of a key decode to a key of the right size
+		for _, lookalike := range []string{
+			hex.EncodeToString([]byte(rawKey)),
+			strings.Repeat("0f", len(encoded))[:len(encoded)],
+			encoded,
+			base64.StdEncoding.EncodeToString([]byte(rawKey)),
+		} {
+			_, err = ki.KeyImport(lookalike, alg, WithRawKey[string]())
+			assert.Errorf(t, err, "KeyImport of %s with raw key %q should fail", alg, lookalike)
+		}
+
+		k, err := ki.KeyImport(encoded, alg, WithBase64RawKey[string]())
+		assert.NoErrorf(t, err, "KeyImport of %s failed: %s", alg, err)
+
+		exported, err := k.Export()
+		assert.NoErrorf(t, err, "Export failed: %s", err)
+		assert.Equal(t, encoded, exported, "Export failed")
+
+		for _, invalid := range []string{
+			rawKey,
+			hex.EncodeToString([]byte(rawKey)),
+			base64.RawStdEncoding.EncodeToString([]byte(rawKey[1:])),
+			base64.RawStdEncoding.EncodeToString([]byte(rawKey + "k")),
+		} {
+			_, err = ki.KeyImport(invalid, alg, WithBase64RawKey[string]())
+			assert.Errorf(t, err, "KeyImport of %s with base64 raw key %q should fail", alg, invalid)
+		}
+	}
+}
+
+func TestPasswordEncryptAndDecrypt(t *testing.T) {
+	kdf, err := new(argon2.KeyGeneratorImpl[string]).KeyGen(types.Argon2, argon2.WithMemory[string](8*1024))
+	assert.NoErrorf(t, err, "KeyGen failed: %s", err)
+
+	ki := new(KeyImportImpl[string])
+
+	k, err := ki.KeyImport("123456", types.XChacha20, WithPassword[string](kdf))
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	ct, err := k.Encrypt("hello world")
+	assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+	assert.Contains(t, ct, types.XChacha20+".argon2id$v=19$m=8192,t=1,p=4$", "Encrypt failed")
+
+	plaintext, err := k.Decrypt(ct)
+	assert.NoErrorf(t, err, "Decrypt failed: %s", err)
+	assert.Equal(t, "hello world", plaintext, "Decrypt failed")
+
+	other, err := ki.KeyImport("654321", types.XChacha20, WithPassword[string](kdf))
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	plaintext, err = other.Decrypt(ct)
+	assert.NoErrorf(t, err, "Decrypt failed: %s", err)
+	assert.NotEqual(t, "hello world", plaintext, "Decrypt with wrong password should not recover the plaintext")
+}
+
+func TestPoly1305EncryptAndDecryptWithAAD(t *testing.T) {
+	tcs := []struct {
+		algorithm types.Algorithm
+	}{
+		{
+			algorithm: types.Chacha20Poly1305,
+		},
+		{
+			algorithm: types.XChacha20Poly1305,
+		},
+	}
+
+	for _, tc := range tcs {
+		ki := new(KeyImportImpl[string])
+
+		k, err := ki.KeyImport("123456", tc.algorithm)
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		aead, ok := k.(key.AEADKey[string])
+		assert.True(t, ok, "key does not implement AEADKey")
+
+		ct, err := aead.EncryptWithAAD("hello world", "header")
+		assert.NoErrorf(t, err, "EncryptWithAAD failed: %s", err)
+
+		plaintext, err := aead.DecryptWithAAD(ct, "header")
+		assert.NoErrorf(t, err, "DecryptWithAAD failed: %s", err)
+		assert.Equal(t, "hello world", plaintext, "DecryptWithAAD failed")
+
+		_, err = aead.DecryptWithAAD(ct, "other header")
+		assert.ErrorIs(t, err, ErrAuthenticationFailed, "DecryptWithAAD with wrong AAD should fail")
+
+		_, err = aead.Decrypt(ct)
+		assert.ErrorIs(t, err, ErrAuthenticationFailed, "Decrypt without AAD should fail")
+	}
+}
+
+func TestPoly1305TamperedCiphertext(t *testing.T) {
+	ki := new(KeyImportImpl[[]byte])
+
+	k, err := ki.KeyImport("123456", types.XChacha20Poly1305)
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	ct, err := k.Encrypt([]byte("hello world"))
+	assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+
+	payload, err := base64.RawStdEncoding.DecodeString(string(ct[len(types.XChacha20Poly1305)+1:]))
+	assert.NoErrorf(t, err, "DecodeString failed: %s", err)
+
+	for i := range payload {
+		tampered := bytes.Clone(payload)
+		tampered[i] ^= 0x01
+
+		_, err = k.Decrypt([]byte(types.XChacha20Poly1305 + "." + base64.RawStdEncoding.EncodeToString(tampered)))
+		assert.ErrorIs(t, err, ErrAuthenticationFailed, "Decrypt tampered ciphertext should fail")
+	}
+
+	_, err = k.Decrypt([]byte(types.XChacha20Poly1305 + "." + base64.RawStdEncoding.EncodeToString(payload[:20])))
+	assert.Error(t, err, "Decrypt truncated ciphertext should fail")
+}
+
+func TestPoly1305RawKeyInterop(t *testing.T) {
+	rawKey := bytes.Repeat([]byte{0x42}, chacha20poly1305.KeySize)
+	nonce := bytes.Repeat([]byte{0x24}, chacha20poly1305.NonceSize)
+
+	aead, err := chacha20poly1305.New(rawKey)
+	assert.NoErrorf(t, err, "New failed: %s", err)
+
+	sealed := aead.Seal(bytes.Clone(nonce), nonce, []byte("hello world"), []byte("header"))
+
+	ki := new(KeyImportImpl[string])
+
+	k, err := ki.KeyImport(rawKey, types.Chacha20Poly1305, WithRawKey[string]())
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	plaintext, err := k.(key.AEADKey[string]).DecryptWithAAD(
+		types.Chacha20Poly1305+"."+base64.RawStdEncoding.EncodeToString(sealed), "header")
+	assert.NoErrorf(t, err, "DecryptWithAAD failed: %s", err)
+	assert.Equal(t, "hello world", plaintext, "DecryptWithAAD failed")
+}
+
+func TestPoly1305PasswordEncryptAndDecrypt(t *testing.T) {
+	kdf, err := new(argon2.KeyGeneratorImpl[string]).KeyGen(types.Argon2, argon2.WithMemory[string](8*1024))
+	assert.NoErrorf(t, err, "KeyGen failed: %s", err)
+
+	ki := new(KeyImportImpl[string])
+
+	k, err := ki.KeyImport("123456", types.XChacha20Poly1305, WithPassword[string](kdf))
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	aead, ok := k.(key.AEADKey[string])
+	assert.True(t, ok, "key does not implement AEADKey")
+
+	ct, err := aead.EncryptWithAAD("hello world", "header")
+	assert.NoErrorf(t, err, "EncryptWithAAD failed: %s", err)
+
+	plaintext, err := aead.DecryptWithAAD(ct, "header")
+	assert.NoErrorf(t, err, "DecryptWithAAD failed: %s", err)
+	assert.Equal(t, "hello world", plaintext, "DecryptWithAAD failed")
+
+	other, err := ki.KeyImport("654321", types.XChacha20Poly1305, WithPassword[string](kdf))
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	_, err = other.Decrypt(ct)
+	assert.ErrorIs(t, err, ErrAuthenticationFailed, "Decrypt with wrong password should fail")
+}
+
+func TestPoly1305StreamingEncryptAndDecrypt(t *testing.T) {
+	for _, alg := range []types.Algorithm{types.Chacha20Poly1305, types.XChacha20Poly1305} {
+		k, err := new(KeyImportImpl[string]).KeyImport("123456", alg)
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		sk, ok := k.(key.StreamingKey)
+		assert.True(t, ok, "key does not implement StreamingKey")
+
+		plaintext := bytes.Repeat([]byte("hello world"), 10000)
+		ciphertext := bytes.NewBuffer(nil)
+
+		w, err := sk.NewEncryptingWriter(ciphertext)
+		assert.NoErrorf(t, err, "NewEncryptingWriter failed: %s", err)
+
+		_, err = w.Write(plaintext)
+		assert.NoErrorf(t, err, "Write failed: %s", err)
+		assert.NoError(t, w.Close(), "Close failed")
+
+		r, err := sk.NewDecryptingReader(bytes.NewReader(ciphertext.Bytes()))
+		assert.NoErrorf(t, err, "NewDecryptingReader failed: %s", err)
+
+		decrypted, err := io.ReadAll(r)
+		assert.NoErrorf(t, err, "ReadAll failed: %s", err)
+		assert.Equal(t, plaintext, decrypted, "decrypt failed")
+
+		r, err = sk.NewDecryptingReader(bytes.NewReader(ciphertext.Bytes()[:ciphertext.Len()-1]))
+		assert.NoErrorf(t, err, "NewDecryptingReader failed: %s", err)
+
+		_, err = io.ReadAll(r)
+		assert.Error(t, err, "decrypt truncated stream should fail")
+	}
+}
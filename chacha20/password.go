@@ -0,0 +1,57 @@
+package chacha20
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/chacha20"
+
+	"github.com/yakumioto/dipper/internal/password"
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+)
+
+// WithPassword treats the imported key as a password. Every encryption derives a fresh ChaCha20 key
+// from the password with a random salt using kdf, and stores the salt and the KDF parameters in
+// the ciphertext header so that Decrypt can derive the key again.
+//
+// kdf must be an Argon2 or PBKDF2 key created with KeyGenerate. If kdf is nil, Argon2id with the
+// default parameters of the argon2 package is used.
+func WithPassword[T types.DataType](kdf key.Key[T]) key.Option[T] {
+	return func(k key.Key[T]) error {
+		if mk, ok := k.(materialKey); ok {
+			deriver, err := password.Deriver(kdf)
+			if err != nil {
+				return fmt.Errorf("chacha20: %w", err)
+			}
+
+			mk.material().deriver = deriver
+			return nil
+		}
+		return errors.New("chacha20: invalid key type")
+	}
+}
+
+// PasswordKeyImpl is a ChaCha20 key derived from a password for every encryption.
+// The ciphertext format is {algorithm}.{kdf header}${base64 payload}, where the payload is the
+// same as that of KeyImpl.
+type PasswordKeyImpl[T types.DataType] struct {
+	*password.Key[T]
+}
+
+func newPasswordKeyImpl[T types.DataType](alg types.Algorithm, pass []byte, nonceSize int, deriver key.Deriver) *PasswordKeyImpl[T] {
+	cipher := password.Cipher[T]{
+		Name:    "chacha20",
+		KeySize: chacha20.KeySize,
+		NewKey: func(derivedKey []byte) (key.Key[T], error) {
+			return newKeyImpl[T](keyMaterial{
+				inputKey:  pass,
+				expendKey: derivedKey,
+				nonceSize: nonceSize,
+				algorithm: alg,
+			}), nil
+		},
+		ErrUnsupportedMethod: ErrUnsupportedMethod,
+	}
+	return &PasswordKeyImpl[T]{password.New(cipher, alg, pass, deriver)}
+}
@@ -0,0 +1,144 @@
+package chacha20
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/stream"
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+var (
+	ErrAuthenticationFailed = errors.New("chacha20-poly1305: message authentication failed")
+)
+
+// Poly1305KeyImpl is a ChaCha20-Poly1305 (RFC 8439) or XChaCha20-Poly1305 key.
+// The ciphertext format is {algorithm}.{base64 nonce||ciphertext||tag}.
+type Poly1305KeyImpl[T types.DataType] struct {
+	keyMaterial
+}
+
+func (k *Poly1305KeyImpl[T]) Algorithm() types.Algorithm {
+	return k.algorithm
+}
+
+func (k *Poly1305KeyImpl[T]) Export() (key T, err error) {
+	return T(k.export()), nil
+}
+
+func (k *Poly1305KeyImpl[T]) SKI() T {
+	sha := sha256.New()
+	sha.Write(k.inputKey)
+
+	return T(utils.ToHexString(sha.Sum(nil)))
+}
+
+func (k *Poly1305KeyImpl[T]) PublicKey() (key.Key[T], error) {
+	return nil, ErrUnsupportedMethod
+}
+
+func (k *Poly1305KeyImpl[T]) Sign(_ T) (signature T, err error) {
+	return T(""), ErrUnsupportedMethod
+}
+
+func (k *Poly1305KeyImpl[T]) Verify(_, _ T) (bool, error) {
+	return false, ErrUnsupportedMethod
+}
+
+func (k *Poly1305KeyImpl[T]) Encrypt(plaintext T) (ciphertext T, err error) {
+	return k.EncryptWithAAD(plaintext, T(""))
+}
+
+func (k *Poly1305KeyImpl[T]) EncryptWithAAD(plaintext, additionalData T) (ciphertext T, err error) {
+	aead, err := k.aead()
+	if err != nil {
+		return T(""), fmt.Errorf("chacha20-poly1305: encrypt failed to create cipher: %w", err)
+	}
+
+	nonce, err := utils.RandomSize(aead.NonceSize())
+	if err != nil {
+		return T(""), fmt.Errorf("chacha20-poly1305: encrypt failed to generate random nonce: %w", err)
+	}
+
+	payload := aead.Seal(nonce, nonce, utils.ToBytes(plaintext), utils.ToBytes(additionalData))
+
+	data := bytes.NewBuffer(nil)
+	data.WriteString(k.algorithm)
+	data.WriteString(".")
+	data.WriteString(base64.RawStdEncoding.EncodeToString(payload))
+
+	return T(data.String()), nil
+}
+
+func (k *Poly1305KeyImpl[T]) Decrypt(ciphertext T) (plaintext T, err error) {
+	return k.DecryptWithAAD(ciphertext, T(""))
+}
+
+func (k *Poly1305KeyImpl[T]) DecryptWithAAD(ciphertext, additionalData T) (plaintext T, err error) {
+	dataBytes := utils.ToString(ciphertext)
+	parts := strings.SplitN(dataBytes, ".", 2)
+	if len(parts) != 2 {
+		return T(""), errors.New("chacha20-poly1305: invalid encrypted data structure")
+	}
+
+	algorithm, payload := parts[0], parts[1]
+
+	if algorithm != k.algorithm {
+		return T(""), fmt.Errorf("chacha20-poly1305: invalid algorithm type: %s", algorithm)
+	}
+
+	encryptedPayload, err := base64.RawStdEncoding.DecodeString(payload)
+	if err != nil {
+		return T(""), fmt.Errorf("chacha20-poly1305: decrypt failed to decode base64: %w", err)
+	}
+
+	aead, err := k.aead()
+	if err != nil {
+		return T(""), fmt.Errorf("chacha20-poly1305: decrypt failed to create cipher: %w", err)
+	}
+
+	if len(encryptedPayload) < aead.NonceSize()+aead.Overhead() {
+		return T(""), errors.New("chacha20-poly1305: ciphertext too short")
+	}
+
+	nonce, ciphertextBytes := encryptedPayload[:aead.NonceSize()], encryptedPayload[aead.NonceSize():]
+
+	plaintextBytes, err := aead.Open(nil, nonce, ciphertextBytes, utils.ToBytes(additionalData))
+	if err != nil {
+		return T(""), ErrAuthenticationFailed
+	}
+
+	return T(plaintextBytes), nil
+}
+
+// NewEncryptingWriter returns a writer that encrypts everything written to it into w with the
+// STREAM construction of the stream package. Close must be called to finish the stream.
+func (k *Poly1305KeyImpl[T]) NewEncryptingWriter(w io.Writer) (io.WriteCloser, error) {
+	return stream.NewWriter(w, k.algorithm, k.expendKey, k.newAEAD)
+}
+
+// NewDecryptingReader returns a reader that decrypts a stream written by NewEncryptingWriter.
+func (k *Poly1305KeyImpl[T]) NewDecryptingReader(r io.Reader) (io.Reader, error) {
+	return stream.NewReader(r, k.algorithm, k.expendKey, k.newAEAD)
+}
+
+func (k *Poly1305KeyImpl[T]) aead() (cipher.AEAD, error) {
+	return k.newAEAD(k.expendKey)
+}
+
+func (k *Poly1305KeyImpl[T]) newAEAD(key []byte) (cipher.AEAD, error) {
+	if k.nonceSize == chacha20poly1305.NonceSizeX {
+		return chacha20poly1305.NewX(key)
+	}
+	return chacha20poly1305.New(key)
+}
@@ -2,9 +2,11 @@ package dipper
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/yakumioto/dipper/aes"
 	"github.com/yakumioto/dipper/argon2"
+	"github.com/yakumioto/dipper/chacha20"
 	"github.com/yakumioto/dipper/ecdsa"
 	"github.com/yakumioto/dipper/hmac"
 	"github.com/yakumioto/dipper/key"
@@ -14,37 +16,105 @@ import (
 )
 
 // KeyImport is a function that imports a cryptographic key based on a given raw data and algorithm.
-// It supports HMAC SHA, AES CBC, AES GCM, ECDSA, and RSA algorithms.
+// It supports every algorithm registered with key.Register, which includes HMAC SHA, AES, ChaCha20,
+// ECDSA, and RSA algorithms. The built-in algorithms also support named data types, such as
+// type Token string.
 // If the algorithm is not supported, it returns an error.
 func KeyImport[T types.DataType](alg types.Algorithm, raw interface{}, opts ...key.Option[T]) (key.Key[T], error) {
-	switch alg {
-	case types.HmacSha256, types.HmacSha512:
-		return new(hmac.ShaKeyImportImpl[T]).KeyImport(raw, alg, opts...)
-	case types.AesCbc128, types.AesCbc192, types.AesCbc256, types.AesGcm128, types.AesGcm192, types.AesGcm256:
-		return new(aes.KeyImportImpl[T]).KeyImport(raw, alg, opts...)
-	case types.EcdsaP256, types.EcdsaP384:
-		return new(ecdsa.KeyImportImpl[T]).KeyImport(raw, alg, opts...)
-	case types.Rsa1024, types.Rsa2048, types.Rsa4096:
-		return new(rsa.KeyImportImpl[T]).KeyImport(raw, alg, opts...)
-	default:
-		return nil, fmt.Errorf("unsupported algorithm: %v", alg)
+	importer, ok := key.LookupImporter[T](alg)
+	if !ok {
+		importer, ok = builtinImporter[T](alg)
+	}
+	if !ok {
+		return nil, unsupported[T](alg, "importer")
 	}
+
+	return importer.KeyImport(raw, alg, opts...)
 }
 
 // KeyGenerate is a function that generates a cryptographic key based on a given algorithm.
-// It supports ECDSA and RSA algorithms.
+// It supports every algorithm registered with key.Register, which includes HMAC SHA, AES, ChaCha20,
+// ECDSA, RSA, PBKDF2 and Argon2 algorithms. Symmetric keys are generated from random bytes and
+// exported in a form that KeyImport accepts. The built-in algorithms also support named data
+// types, such as type Token string.
 // If the algorithm is not supported, it returns an error.
 func KeyGenerate[T types.DataType](alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
-	switch alg {
-	case types.EcdsaP256, types.EcdsaP384:
-		return new(ecdsa.KeyGeneratorImpl[T]).KeyGen(alg, opts...)
-	case types.Rsa1024, types.Rsa2048, types.Rsa4096:
-		return new(rsa.KeyGeneratorImpl[T]).KeyGen(alg, opts...)
-	case types.Pbkdf2Sha256, types.Pbkdf2Sha512:
-		return new(pbkdf2.KeyGeneratorImpl[T]).KeyGen(alg, opts...)
-	case types.Argon2:
-		return new(argon2.KeyGeneratorImpl[T]).KeyGen(alg, opts...)
+	generator, ok := key.LookupGenerator[T](alg)
+	if !ok {
+		generator, ok = builtinGenerator[T](alg)
+	}
+	if !ok {
+		return nil, unsupported[T](alg, "generator")
+	}
+
+	return generator.KeyGen(alg, opts...)
+}
+
+// Algorithms returns the sorted names of all registered algorithms.
+func Algorithms() []types.Algorithm {
+	return key.Algorithms()
+}
+
+// builtinImporter returns the importer of a built-in algorithm for a named data type. Algorithms
+// are registered for string and []byte, so the importer registered for string is instantiated
+// again for T.
+func builtinImporter[T types.DataType](alg types.Algorithm) (key.Importer[T], bool) {
+	importer, ok := key.LookupImporter[string](alg)
+	if !ok {
+		return nil, false
+	}
+
+	switch importer.(type) {
+	case *aes.KeyImportImpl[string]:
+		return new(aes.KeyImportImpl[T]), true
+	case *chacha20.KeyImportImpl[string]:
+		return new(chacha20.KeyImportImpl[T]), true
+	case *ecdsa.KeyImportImpl[string]:
+		return new(ecdsa.KeyImportImpl[T]), true
+	case *hmac.ShaKeyImportImpl[string]:
+		return new(hmac.ShaKeyImportImpl[T]), true
+	case *rsa.KeyImportImpl[string]:
+		return new(rsa.KeyImportImpl[T]), true
+	default:
+		return nil, false
+	}
+}
+
+// builtinGenerator returns the generator of a built-in algorithm for a named data type, like
+// builtinImporter.
+func builtinGenerator[T types.DataType](alg types.Algorithm) (key.Generator[T], bool) {
+	generator, ok := key.LookupGenerator[string](alg)
+	if !ok {
+		return nil, false
+	}
+
+	switch generator.(type) {
+	case *aes.KeyGeneratorImpl[string]:
+		return new(aes.KeyGeneratorImpl[T]), true
+	case *argon2.KeyGeneratorImpl[string]:
+		return new(argon2.KeyGeneratorImpl[T]), true
+	case *chacha20.KeyGeneratorImpl[string]:
+		return new(chacha20.KeyGeneratorImpl[T]), true
+	case *ecdsa.KeyGeneratorImpl[string]:
+		return new(ecdsa.KeyGeneratorImpl[T]), true
+	case *hmac.ShaKeyGeneratorImpl[string]:
+		return new(hmac.ShaKeyGeneratorImpl[T]), true
+	case *pbkdf2.KeyGeneratorImpl[string]:
+		return new(pbkdf2.KeyGeneratorImpl[T]), true
+	case *rsa.KeyGeneratorImpl[string]:
+		return new(rsa.KeyGeneratorImpl[T]), true
 	default:
-		return nil, fmt.Errorf("unsupported algorithm: %v", alg)
+		return nil, false
+	}
+}
+
+// unsupported returns the error of an algorithm without the given importer or generator for T.
+// The error only reports an unsupported algorithm when the algorithm is unknown.
+func unsupported[T types.DataType](alg types.Algorithm, method string) error {
+	for _, registered := range key.Algorithms() {
+		if registered == alg {
+			return fmt.Errorf("no %s registered for algorithm %v and %v", method, alg, reflect.TypeOf((*T)(nil)).Elem())
+		}
 	}
+	return fmt.Errorf("unsupported algorithm: %v", alg)
 }
@@ -7,13 +7,14 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	_ "crypto/sha512"
 	"crypto/subtle"
-	"crypto/x509"
+	"encoding/asn1"
 	"encoding/base64"
 	"encoding/hex"
-	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
 
 	"github.com/yakumioto/dipper/key"
@@ -25,9 +26,42 @@ var (
 	ErrUnsupportedMethod = errors.New("ecdsa: unsupported method")
 )
 
+// WithHash sets the hash function used to digest messages before signing and verification.
+// By default the hash matches the curve: SHA-256 for P-256, SHA-384 for P-384 and SHA-512 for P-521.
+// Signatures made with another hash, such as SHA-256 signatures of P-384 keys created by earlier
+// versions, can only be verified by keys imported with that hash.
+func WithHash[T types.DataType](hash crypto.Hash) key.Option[T] {
+	return func(k key.Key[T]) error {
+		if !hash.Available() {
+			return fmt.Errorf("ecdsa: unavailable hash function: %v", hash)
+		}
+		return withHash[T](hashFunc{hash: hash})(k)
+	}
+}
+
+func withHash[T types.DataType](h hashFunc) key.Option[T] {
+	return func(k key.Key[T]) error {
+		switch k := k.(type) {
+		case *PrivateKey[T]:
+			k.hash = h
+		case *PublicKey[T]:
+			k.hash = h
+		default:
+			return errors.New("ecdsa: invalid key type")
+		}
+		return nil
+	}
+}
+
 type PrivateKey[T types.DataType] struct {
-	privateKey *ecdsa.PrivateKey
-	algorithm  types.Algorithm
+	privateKey  *ecdsa.PrivateKey
+	algorithm   types.Algorithm
+	hash        hashFunc
+	nonce       nonceMode
+	p1363       bool
+	format      Format
+	der         bool
+	recoverable bool
 }
 
 func (e *PrivateKey[T]) Algorithm() types.Algorithm {
@@ -35,12 +69,21 @@ func (e *PrivateKey[T]) Algorithm() types.Algorithm {
 }
 
 func (e *PrivateKey[T]) Export() (key T, err error) {
-	pkcs8Encoded, err := x509.MarshalPKCS8PrivateKey(e.privateKey)
+	if e.format == FormatSEC1 {
+		sec1Encoded, err := marshalSEC1(e.privateKey)
+		if err != nil {
+			return T(""), fmt.Errorf("ecdsa: failed to marshal private key: %w", err)
+		}
+
+		return T(encode(sec1Encoded, "EC PRIVATE KEY", e.der)), nil
+	}
+
+	pkcs8Encoded, err := marshalPKCS8(e.privateKey)
 	if err != nil {
-		return T(""), fmt.Errorf("ecdsa: failed to marshal private pubKey: %w", err)
+		return T(""), fmt.Errorf("ecdsa: failed to marshal private key: %w", err)
 	}
 
-	return T(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: pkcs8Encoded})), nil
+	return T(encode(pkcs8Encoded, "PRIVATE KEY", e.der)), nil
 }
 
 func (e *PrivateKey[T]) SKI() T {
@@ -50,22 +93,55 @@ func (e *PrivateKey[T]) SKI() T {
 
 func (e *PrivateKey[T]) PublicKey() (key.Key[T], error) {
 	return &PublicKey[T]{
-		algorithm: e.algorithm,
-		publicKey: &e.privateKey.PublicKey}, nil
+		algorithm:   e.algorithm,
+		publicKey:   &e.privateKey.PublicKey,
+		hash:        e.hash,
+		p1363:       e.p1363,
+		der:         e.der,
+		recoverable: e.recoverable}, nil
 }
 
 func (e *PrivateKey[T]) Sign(msg T) (signature T, err error) {
-	h := sha256.New()
+	h := e.hash.New()
 	if _, err = h.Write(utils.ToBytes(msg)); err != nil {
 		return T(""), fmt.Errorf("ecdsa: failed to write message bytes to hash: %w", err)
 	}
-	digest := h.Sum(nil)
 
-	payload, err := e.privateKey.Sign(rand.Reader, digest, crypto.SHA256)
+	return e.signDigest(h.Sum(nil))
+}
+
+// SignDigest signs a digest computed with the hash of the key.
+func (e *PrivateKey[T]) SignDigest(digest []byte, hash crypto.Hash) (signature T, err error) {
+	if err = checkDigest(e.hash, digest, hash); err != nil {
+		return T(""), err
+	}
+
+	return e.signDigest(digest)
+}
+
+func (e *PrivateKey[T]) signDigest(digest []byte) (T, error) {
+	payload, err := e.sign(digest)
 	if err != nil {
 		return T(""), fmt.Errorf("ecdsa: failed to sign message: %w", err)
 	}
 
+	if isSecp256k1(e.privateKey.Curve) {
+		if payload, err = normalizeLowS(e.privateKey.Curve, payload); err != nil {
+			return T(""), fmt.Errorf("ecdsa: failed to normalize signature: %w", err)
+		}
+	}
+
+	switch {
+	case e.recoverable:
+		if payload, err = asn1ToRecoverable(&e.privateKey.PublicKey, digest, payload); err != nil {
+			return T(""), fmt.Errorf("ecdsa: failed to encode recoverable signature: %w", err)
+		}
+	case e.p1363:
+		if payload, err = asn1ToP1363(e.privateKey.Curve, payload); err != nil {
+			return T(""), fmt.Errorf("ecdsa: failed to encode p1363 signature: %w", err)
+		}
+	}
+
 	data := bytes.NewBuffer(nil)
 	data.WriteString(e.algorithm)
 	data.WriteString(".")
@@ -76,22 +152,79 @@ func (e *PrivateKey[T]) Sign(msg T) (signature T, err error) {
 	return T(data.Bytes()), nil
 }
 
+func (e *PrivateKey[T]) sign(digest []byte) ([]byte, error) {
+	switch e.nonce {
+	case nonceDeterministic:
+		return signRFC6979(e.privateKey, e.hash, digest, nil)
+	case nonceHedged:
+		extra, err := utils.RandomSize(e.hash.Size())
+		if err != nil {
+			return nil, err
+		}
+		return signRFC6979(e.privateKey, e.hash, digest, extra)
+	default:
+		return ecdsa.SignASN1(rand.Reader, e.privateKey, digest)
+	}
+}
+
 func (e *PrivateKey[T]) Verify(_, _ T) (bool, error) {
 
 	return false, ErrUnsupportedMethod
 }
 
+func (e *PrivateKey[T]) VerifyDigest(_ []byte, _ crypto.Hash, _ T) (bool, error) {
+	return false, ErrUnsupportedMethod
+}
+
 func (e *PrivateKey[T]) Encrypt(_ T) (ciphertext T, err error) {
 	return T(""), ErrUnsupportedMethod
 }
 
-func (e *PrivateKey[T]) Decrypt(_ T) (plaintext T, err error) {
-	return T(""), ErrUnsupportedMethod
+// Decrypt decrypts an ECIES ciphertext made by PublicKey.Encrypt. ECIES is only supported on the
+// NIST curves, since crypto/ecdh does not implement secp256k1.
+func (e *PrivateKey[T]) Decrypt(ciphertext T) (plaintext T, err error) {
+	if isSecp256k1(e.privateKey.Curve) {
+		return T(""), ErrUnsupportedMethod
+	}
+
+	dataBytes := utils.ToString(ciphertext)
+
+	parts := strings.SplitN(dataBytes, ".", 3)
+	if len(parts) != 3 {
+		return T(""), errors.New("ecdsa: invalid encrypted data structure")
+	}
+
+	algorithm, encodedEphemeral, encodedPayload := parts[0], parts[1], parts[2]
+
+	if algorithm != e.algorithm {
+		return T(""), fmt.Errorf("ecdsa: invalid algorithm type: %s", algorithm)
+	}
+
+	ephemeral, err := base64.RawStdEncoding.DecodeString(encodedEphemeral)
+	if err != nil {
+		return T(""), fmt.Errorf("ecdsa: decrypt ephemeral public key failed to decode base64: %w", err)
+	}
+
+	payload, err := base64.RawStdEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return T(""), fmt.Errorf("ecdsa: decrypt failed to decode base64: %w", err)
+	}
+
+	data, err := eciesDecrypt(e.algorithm, e.privateKey, ephemeral, payload)
+	if err != nil {
+		return T(""), fmt.Errorf("ecdsa: decrypt error: %w", err)
+	}
+
+	return T(data), nil
 }
 
 type PublicKey[T types.DataType] struct {
-	publicKey *ecdsa.PublicKey
-	algorithm types.Algorithm
+	publicKey   *ecdsa.PublicKey
+	algorithm   types.Algorithm
+	hash        hashFunc
+	p1363       bool
+	der         bool
+	recoverable bool
 }
 
 func (e *PublicKey[T]) Algorithm() types.Algorithm {
@@ -99,11 +232,11 @@ func (e *PublicKey[T]) Algorithm() types.Algorithm {
 }
 
 func (e *PublicKey[T]) Export() (key T, err error) {
-	pkcs8Encoded, err := x509.MarshalPKIXPublicKey(e.publicKey)
+	pkcs8Encoded, err := marshalPKIX(e.publicKey)
 	if err != nil {
 		return T(""), fmt.Errorf("ecdsa: failed to marshal public pubKey: %w", err)
 	}
-	return T(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkcs8Encoded})), nil
+	return T(encode(pkcs8Encoded, "PUBLIC KEY", e.der)), nil
 }
 
 func (e *PublicKey[T]) SKI() T {
@@ -122,7 +255,29 @@ func (e *PublicKey[T]) Sign(_ T) (T, error) {
 	return T(""), ErrUnsupportedMethod
 }
 
+func (e *PublicKey[T]) SignDigest(_ []byte, _ crypto.Hash) (T, error) {
+	return T(""), ErrUnsupportedMethod
+}
+
 func (e *PublicKey[T]) Verify(msg, signature T) (bool, error) {
+	h := e.hash.New()
+	if _, err := h.Write(utils.ToBytes(msg)); err != nil {
+		return false, fmt.Errorf("ecdsa: failed to compute message : %w", err)
+	}
+
+	return e.verifyDigest(h.Sum(nil), signature)
+}
+
+// VerifyDigest verifies a signature of a digest computed with the hash of the key.
+func (e *PublicKey[T]) VerifyDigest(digest []byte, hash crypto.Hash, signature T) (bool, error) {
+	if err := checkDigest(e.hash, digest, hash); err != nil {
+		return false, err
+	}
+
+	return e.verifyDigest(digest, signature)
+}
+
+func (e *PublicKey[T]) verifyDigest(digest []byte, signature T) (bool, error) {
 	dataBytes := utils.ToString(signature)
 
 	parts := strings.SplitN(dataBytes, ".", 3)
@@ -146,44 +301,84 @@ func (e *PublicKey[T]) Verify(msg, signature T) (bool, error) {
 		return false, fmt.Errorf("ecdsa: decrypt provided signature failed to decode base64: %w", err)
 	}
 
-	h := sha256.New()
-	if _, err = h.Write(utils.ToBytes(msg)); err != nil {
-		return false, fmt.Errorf("ecdsa: failed to compute message : %w", err)
-	}
-	digest := h.Sum(nil)
-
 	if subtle.ConstantTimeCompare(digest, providedDigest) == 0 {
 		return false, fmt.Errorf("ecdsa: invalid digest")
 	}
 
-	if !ecdsa.VerifyASN1(e.publicKey, digest, providedSignature) {
+	var r, s *big.Int
+
+	switch {
+	case e.recoverable:
+		if r, s, err = recoverableToRS(e.publicKey.Curve, providedSignature); err != nil {
+			return false, fmt.Errorf("ecdsa: %w", err)
+		}
+
+		recovered, err := recoverSecp256k1(digest, providedSignature)
+		if err != nil || !recovered.Equal(e.publicKey) {
+			return false, nil
+		}
+	case e.p1363:
+		if r, s, err = p1363ToRS(e.publicKey.Curve, providedSignature); err != nil {
+			return false, fmt.Errorf("ecdsa: %w", err)
+		}
+	default:
+		var rs struct{ R, S *big.Int }
+		if rest, err := asn1.Unmarshal(providedSignature, &rs); err != nil || len(rest) != 0 {
+			return false, nil
+		}
+		r, s = rs.R, rs.S
+	}
+
+	if isSecp256k1(e.publicKey.Curve) && !isLowS(e.publicKey.Curve, s) {
+		return false, errors.New("ecdsa: signature is not low-S normalized")
+	}
+
+	if r.Sign() <= 0 || s.Sign() <= 0 {
 		return false, nil
 	}
 
-	return true, nil
+	return ecdsa.Verify(e.publicKey, digest, r, s), nil
 }
 
-func (e *PublicKey[T]) Encrypt(_ T) (T, error) {
-	return T(""), ErrUnsupportedMethod
+// Encrypt encrypts plaintext with ECIES. ECIES is only supported on the NIST curves, since
+// crypto/ecdh does not implement secp256k1.
+func (e *PublicKey[T]) Encrypt(plaintext T) (T, error) {
+	if isSecp256k1(e.publicKey.Curve) {
+		return T(""), ErrUnsupportedMethod
+	}
+
+	ephemeral, payload, err := eciesEncrypt(e.algorithm, e.publicKey, utils.ToBytes(plaintext))
+	if err != nil {
+		return T(""), fmt.Errorf("ecdsa: failed to encrypt message: %w", err)
+	}
+
+	data := bytes.NewBuffer(nil)
+	data.WriteString(e.algorithm)
+	data.WriteString(".")
+	data.WriteString(base64.RawStdEncoding.EncodeToString(ephemeral))
+	data.WriteString(".")
+	data.WriteString(base64.RawStdEncoding.EncodeToString(payload))
+
+	return T(data.Bytes()), nil
 }
 
 func (e *PublicKey[T]) Decrypt(_ T) (T, error) {
 	return T(""), ErrUnsupportedMethod
 }
 
+func init() {
+	for _, alg := range []types.Algorithm{types.EcdsaP256, types.EcdsaP384, types.EcdsaP521, types.EcdsaSecp256k1} {
+		key.Register[string](alg, new(KeyImportImpl[string]), new(KeyGeneratorImpl[string]))
+		key.Register[[]byte](alg, new(KeyImportImpl[[]byte]), new(KeyGeneratorImpl[[]byte]))
+	}
+}
+
 type KeyGeneratorImpl[T types.DataType] struct{}
 
 func (e *KeyGeneratorImpl[T]) KeyGen(alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
-	var curve elliptic.Curve
-	switch alg {
-	case types.EcdsaP256:
-		curve = elliptic.P256()
-	case types.EcdsaP384:
-		curve = elliptic.P384()
-	case types.EcdsaP521:
-		curve = elliptic.P521()
-	default:
-		return nil, fmt.Errorf("ecdsa: invalid algorithm: %v", alg)
+	curve, err := curveOf(alg)
+	if err != nil {
+		return nil, err
 	}
 
 	privateKey, err := ecdsa.GenerateKey(curve, rand.Reader)
@@ -191,10 +386,43 @@ func (e *KeyGeneratorImpl[T]) KeyGen(alg types.Algorithm, opts ...key.Option[T])
 		return nil, fmt.Errorf("ecdsa: failed to generate private key: %w", err)
 	}
 
-	return &PrivateKey[T]{
+	k := &PrivateKey[T]{
 		algorithm:  alg,
 		privateKey: privateKey,
-	}, nil
+		hash:       hashFunc{hash: defaultHash(alg)},
+	}
+
+	for _, opt := range opts {
+		if err = opt(k); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+// checkDigest reports an error if digest was not computed with the key hash.
+func checkDigest(keyHash hashFunc, digest []byte, hash crypto.Hash) error {
+	if hash != keyHash.CryptoHash() {
+		return fmt.Errorf("ecdsa: digest hash %v does not match key hash %v", hash, keyHash)
+	}
+
+	if len(digest) != keyHash.Size() {
+		return fmt.Errorf("ecdsa: invalid digest length: want %d bytes, got %d", keyHash.Size(), len(digest))
+	}
+	return nil
+}
+
+// defaultHash returns the hash function matching the curve of alg.
+func defaultHash(alg types.Algorithm) crypto.Hash {
+	switch alg {
+	case types.EcdsaP384:
+		return crypto.SHA384
+	case types.EcdsaP521:
+		return crypto.SHA512
+	default:
+		return crypto.SHA256
+	}
 }
 
 type KeyImportImpl[T types.DataType] struct{}
@@ -205,26 +433,43 @@ func (e *KeyImportImpl[T]) KeyImport(raw interface{}, alg types.Algorithm, opts
 		return nil, err
 	}
 
-	block, _ := pem.Decode(keyBytes)
-	if block == nil {
-		return nil, fmt.Errorf("ecdsa: failed to decode pem block")
+	pk, err := parseKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("ecdsa: failed to parse key: %w", err)
 	}
 
-	k, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
-	if pkcs8Err == nil {
-		return &PrivateKey[T]{
+	var k key.Key[T]
+
+	switch pk := pk.(type) {
+	case *ecdsa.PrivateKey:
+		if err = checkCurve(pk.Curve, alg); err != nil {
+			return nil, err
+		}
+
+		k = &PrivateKey[T]{
 			algorithm:  alg,
-			privateKey: k.(*ecdsa.PrivateKey),
-		}, nil
+			privateKey: pk,
+			hash:       hashFunc{hash: defaultHash(alg)},
+		}
+	case *ecdsa.PublicKey:
+		if err = checkCurve(pk.Curve, alg); err != nil {
+			return nil, err
+		}
+
+		k = &PublicKey[T]{
+			algorithm: alg,
+			publicKey: pk,
+			hash:      hashFunc{hash: defaultHash(alg)},
+		}
+	default:
+		return nil, fmt.Errorf("ecdsa: unsupported key type: %T", pk)
 	}
 
-	k, pkixErr := x509.ParsePKIXPublicKey(block.Bytes)
-	if pkixErr == nil {
-		return &PublicKey[T]{
-			algorithm: alg,
-			publicKey: k.(*ecdsa.PublicKey),
-		}, nil
+	for _, opt := range opts {
+		if err = opt(k); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil, fmt.Errorf("ecdsa: failed to parse key pkcs8 error: %w, pkix error: %w", pkcs8Err, pkixErr)
+	return k, nil
 }
@@ -0,0 +1,104 @@
+package ecdsa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+// eciesKeySize is the length of the AES-256-GCM key derived from the ECDH shared secret.
+const eciesKeySize = 32
+
+// eciesEncrypt encrypts plaintext to pub with ECIES: an ephemeral key on the same curve is agreed
+// with pub via ECDH, and an AES-256-GCM key is derived from the shared secret with HKDF-SHA256,
+// using the algorithm and both public keys as info. It returns the uncompressed ephemeral public
+// key and nonce||ciphertext.
+func eciesEncrypt(alg types.Algorithm, pub *ecdsa.PublicKey, plaintext []byte) ([]byte, []byte, error) {
+	recipient, err := pub.ECDH()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ephemeral, err := recipient.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	shared, err := ephemeral.ECDH(recipient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ephemeralBytes := ephemeral.PublicKey().Bytes()
+
+	aead, err := eciesAEAD(alg, shared, ephemeralBytes, recipient.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce, err := utils.RandomSize(aead.NonceSize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ephemeralBytes, aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// eciesDecrypt reverses eciesEncrypt. The ephemeral public key is rejected if it is not a valid
+// point on the curve of priv.
+func eciesDecrypt(alg types.Algorithm, priv *ecdsa.PrivateKey, ephemeralBytes, payload []byte) ([]byte, error) {
+	recipient, err := priv.ECDH()
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeral, err := recipient.Curve().NewPublicKey(ephemeralBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := recipient.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := eciesAEAD(alg, shared, ephemeralBytes, recipient.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	return aead.Open(nil, payload[:aead.NonceSize()], payload[aead.NonceSize():], nil)
+}
+
+// eciesAEAD derives the AES-256-GCM cipher from the shared secret.
+func eciesAEAD(alg types.Algorithm, shared, ephemeral, recipient []byte) (cipher.AEAD, error) {
+	info := make([]byte, 0, len(alg)+len(ephemeral)+len(recipient))
+	info = append(info, alg...)
+	info = append(info, ephemeral...)
+	info = append(info, recipient...)
+
+	key := make([]byte, eciesKeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, nil, info), key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
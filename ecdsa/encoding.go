@@ -0,0 +1,68 @@
+package ecdsa
+
+import (
+	"crypto/elliptic"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+)
+
+// WithP1363Encoding makes Sign emit and Verify accept IEEE P1363 signatures, the fixed-length
+// concatenation r||s used by WebCrypto and JWS, instead of ASN.1 DER. Each of r and s is as long as
+// the curve order, so that a P-256 signature is 64 bytes, a P-384 signature 96 bytes and a P-521
+// signature 132 bytes.
+func WithP1363Encoding[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		switch k := k.(type) {
+		case *PrivateKey[T]:
+			k.p1363 = true
+		case *PublicKey[T]:
+			k.p1363 = true
+		default:
+			return errors.New("ecdsa: invalid key type")
+		}
+		return nil
+	}
+}
+
+// scalarSize returns the length in bytes of each of r and s in a P1363 signature.
+func scalarSize(curve elliptic.Curve) int {
+	return (curve.Params().N.BitLen() + 7) / 8
+}
+
+// asn1ToP1363 converts an ASN.1 DER signature into r||s.
+func asn1ToP1363(curve elliptic.Curve, sig []byte) ([]byte, error) {
+	var rs struct{ R, S *big.Int }
+	rest, err := asn1.Unmarshal(sig, &rs)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after signature")
+	}
+
+	size := scalarSize(curve)
+	if rs.R.Sign() <= 0 || rs.S.Sign() <= 0 || rs.R.BitLen() > size*8 || rs.S.BitLen() > size*8 {
+		return nil, errors.New("invalid signature values")
+	}
+
+	out := make([]byte, 2*size)
+	rs.R.FillBytes(out[:size])
+	rs.S.FillBytes(out[size:])
+
+	return out, nil
+}
+
+// p1363ToRS parses an r||s signature, whose length must match the curve exactly.
+func p1363ToRS(curve elliptic.Curve, sig []byte) (r, s *big.Int, err error) {
+	size := scalarSize(curve)
+	if len(sig) != 2*size {
+		return nil, nil, fmt.Errorf("invalid p1363 signature length: want %d bytes, got %d", 2*size, len(sig))
+	}
+
+	return new(big.Int).SetBytes(sig[:size]), new(big.Int).SetBytes(sig[size:]), nil
+}
@@ -0,0 +1,157 @@
+package ecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+)
+
+// Format is the structure used to export private keys.
+type Format int
+
+const (
+	// FormatPKCS8 exports private keys as PKCS #8, in a PEM block labelled "PRIVATE KEY".
+	FormatPKCS8 Format = iota
+	// FormatSEC1 exports private keys as SEC 1, in a PEM block labelled "EC PRIVATE KEY", the
+	// format written by `openssl ecparam -genkey`.
+	FormatSEC1
+)
+
+// WithFormat sets the structure used by Export for private keys. Public keys are always exported
+// as PKIX.
+func WithFormat[T types.DataType](format Format) key.Option[T] {
+	return func(k key.Key[T]) error {
+		if format != FormatPKCS8 && format != FormatSEC1 {
+			return fmt.Errorf("ecdsa: invalid format: %d", format)
+		}
+
+		if e, ok := k.(*PrivateKey[T]); ok {
+			e.format = format
+			return nil
+		}
+		return errors.New("ecdsa: invalid key type")
+	}
+}
+
+// WithDEREncoding makes Export return the raw DER bytes instead of a PEM block.
+func WithDEREncoding[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		switch k := k.(type) {
+		case *PrivateKey[T]:
+			k.der = true
+		case *PublicKey[T]:
+			k.der = true
+		default:
+			return errors.New("ecdsa: invalid key type")
+		}
+		return nil
+	}
+}
+
+// curveOf returns the curve used by alg.
+func curveOf(alg types.Algorithm) (elliptic.Curve, error) {
+	switch alg {
+	case types.EcdsaP256:
+		return elliptic.P256(), nil
+	case types.EcdsaP384:
+		return elliptic.P384(), nil
+	case types.EcdsaP521:
+		return elliptic.P521(), nil
+	case types.EcdsaSecp256k1:
+		return secp256k1.S256(), nil
+	default:
+		return nil, fmt.Errorf("ecdsa: invalid algorithm: %v", alg)
+	}
+}
+
+// encode returns der as is, or wrapped in a PEM block with the given label.
+func encode(der []byte, label string, raw bool) []byte {
+	if raw {
+		return der
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: label, Bytes: der})
+}
+
+// marshalSEC1 encodes priv as SEC 1.
+func marshalSEC1(priv *ecdsa.PrivateKey) ([]byte, error) {
+	if isSecp256k1(priv.Curve) {
+		return marshalSecp256k1SEC1(priv, oidNamedCurveSecp256k1)
+	}
+	return x509.MarshalECPrivateKey(priv)
+}
+
+// marshalPKCS8 encodes priv as PKCS #8.
+func marshalPKCS8(priv *ecdsa.PrivateKey) ([]byte, error) {
+	if isSecp256k1(priv.Curve) {
+		return marshalSecp256k1PKCS8(priv)
+	}
+	return x509.MarshalPKCS8PrivateKey(priv)
+}
+
+// marshalPKIX encodes pub as PKIX.
+func marshalPKIX(pub *ecdsa.PublicKey) ([]byte, error) {
+	if isSecp256k1(pub.Curve) {
+		return marshalSecp256k1PKIX(pub)
+	}
+	return x509.MarshalPKIXPublicKey(pub)
+}
+
+// parseKey parses a PKCS #8 or SEC 1 private key or a PKIX public key, either PEM or raw DER.
+// The PEM label is not trusted, since earlier versions exported PKCS #8 keys as "EC PRIVATE KEY".
+// "EC PARAMETERS" blocks, which `openssl ecparam -genkey` writes before the key, are skipped.
+func parseKey(keyBytes []byte) (interface{}, error) {
+	der := keyBytes
+	for rest := keyBytes; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+
+		if block.Type != "EC PARAMETERS" {
+			der = block.Bytes
+			break
+		}
+	}
+
+	pk, pkcs8Err := x509.ParsePKCS8PrivateKey(der)
+	if pkcs8Err == nil {
+		return pk, nil
+	}
+
+	pk, sec1Err := x509.ParseECPrivateKey(der)
+	if sec1Err == nil {
+		return pk, nil
+	}
+
+	pk, pkixErr := x509.ParsePKIXPublicKey(der)
+	if pkixErr == nil {
+		return pk, nil
+	}
+
+	if pk, err := parseSecp256k1Key(der); err == nil {
+		return pk, nil
+	}
+
+	return nil, fmt.Errorf("pkcs8 error: %w, sec1 error: %w, pkix error: %w", pkcs8Err, sec1Err, pkixErr)
+}
+
+// checkCurve reports an error if curve is not the curve of alg.
+func checkCurve(curve elliptic.Curve, alg types.Algorithm) error {
+	want, err := curveOf(alg)
+	if err != nil {
+		return err
+	}
+
+	if curve != want {
+		return fmt.Errorf("ecdsa: key curve %s does not match algorithm %s", curve.Params().Name, alg)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package ecdsa
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+)
+
+type nonceMode int
+
+const (
+	nonceRandom nonceMode = iota
+	nonceDeterministic
+	nonceHedged
+)
+
+// WithDeterministicNonce makes the private key derive signature nonces from the key and the message
+// digest as specified in RFC 6979, so that signing the same message always produces the same
+// signature and the key does not depend on the quality of the random number generator.
+//
+// The signing is not constant time. The nonce point is computed in constant time on the NIST
+// curves, but with the variable-time scalar multiplication of the secp256k1 library on
+// ecdsa_secp256k1 keys. The signature itself is computed with the variable-time math/big, which
+// multiplies r by the private key, and only the inversion of the nonce is blinded with a random
+// factor. Where an attacker can measure the signing time, prefer the default random nonces on the
+// NIST curves, for which crypto/ecdsa signs in constant time. On ecdsa_secp256k1 keys, crypto/ecdsa
+// falls back to its variable-time generic implementation as well.
+func WithDeterministicNonce[T types.DataType]() key.Option[T] {
+	return withNonce[T](nonceDeterministic)
+}
+
+// WithHedgedNonce makes the private key derive signature nonces as in RFC 6979 with additional
+// random data (RFC 6979, Section 3.6). Signatures are randomized, but remain secure when the
+// random number generator is weak. It has the timing properties of WithDeterministicNonce.
+func WithHedgedNonce[T types.DataType]() key.Option[T] {
+	return withNonce[T](nonceHedged)
+}
+
+func withNonce[T types.DataType](mode nonceMode) key.Option[T] {
+	return func(k key.Key[T]) error {
+		if e, ok := k.(*PrivateKey[T]); ok {
+			e.nonce = mode
+			return nil
+		}
+		return errors.New("ecdsa: invalid key type")
+	}
+}
+
+// signRFC6979 signs the digest with a nonce generated as specified in RFC 6979, Section 3.2.
+// If extra is not empty, it is added to the HMAC_DRBG seed as specified in Section 3.6.
+// The signature is ASN.1 encoded.
+func signRFC6979(priv *ecdsa.PrivateKey, h hashFunc, digest, extra []byte) ([]byte, error) {
+	curve := priv.Curve
+	q := curve.Params().N
+	qlen := q.BitLen()
+	rlen := (qlen + 7) / 8
+
+	bits2int := func(b []byte) *big.Int {
+		x := new(big.Int).SetBytes(b)
+		if excess := len(b)*8 - qlen; excess > 0 {
+			x.Rsh(x, uint(excess))
+		}
+		return x
+	}
+
+	int2octets := func(x *big.Int) []byte {
+		return x.FillBytes(make([]byte, rlen))
+	}
+
+	e := bits2int(digest)
+	if e.Cmp(q) >= 0 {
+		e.Sub(e, q)
+	}
+
+	x := int2octets(priv.D)
+	h1 := int2octets(e)
+
+	mac := func(k []byte, data ...[]byte) []byte {
+		m := hmac.New(h.New, k)
+		for _, d := range data {
+			m.Write(d)
+		}
+		return m.Sum(nil)
+	}
+
+	v := make([]byte, h.Size())
+	for i := range v {
+		v[i] = 0x01
+	}
+	k := make([]byte, h.Size())
+
+	k = mac(k, v, []byte{0x00}, x, h1, extra)
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, x, h1, extra)
+	v = mac(k, v)
+
+	for {
+		var t []byte
+		for len(t) < rlen {
+			v = mac(k, v)
+			t = append(t, v...)
+		}
+
+		nonce := bits2int(t[:rlen])
+		if nonce.Sign() > 0 && nonce.Cmp(q) < 0 {
+			rx, err := scalarBaseMultX(curve, int2octets(nonce))
+			if err != nil {
+				return nil, err
+			}
+			r := rx.Mod(rx, q)
+
+			if r.Sign() > 0 {
+				kInv, err := blindedInverse(nonce, q)
+				if err != nil {
+					return nil, err
+				}
+
+				s := new(big.Int).Mul(priv.D, r)
+				s.Add(s, e)
+				s.Mul(s, kInv)
+				s.Mod(s, q)
+
+				if s.Sign() > 0 {
+					return asn1.Marshal(struct{ R, S *big.Int }{r, s})
+				}
+			}
+		}
+
+		k = mac(k, v, []byte{0x00})
+		v = mac(k, v)
+	}
+}
+
+// scalarBaseMultX returns the x coordinate of k×G. crypto/ecdh multiplies in constant time on the
+// NIST curves, while secp256k1 is only supported by the variable-time multiplication of the
+// secp256k1 library, which it also uses for its own signatures.
+func scalarBaseMultX(curve elliptic.Curve, k []byte) (*big.Int, error) {
+	if isSecp256k1(curve) {
+		var scalar secp256k1.ModNScalar
+		scalar.SetByteSlice(k)
+		defer scalar.Zero()
+
+		var point secp256k1.JacobianPoint
+		secp256k1.ScalarBaseMultNonConst(&scalar, &point)
+		point.ToAffine()
+
+		x := point.X.Bytes()
+		return new(big.Int).SetBytes(x[:]), nil
+	}
+
+	var ecdhCurve ecdh.Curve
+	switch curve {
+	case elliptic.P256():
+		ecdhCurve = ecdh.P256()
+	case elliptic.P384():
+		ecdhCurve = ecdh.P384()
+	case elliptic.P521():
+		ecdhCurve = ecdh.P521()
+	default:
+		return nil, errors.New("ecdsa: unsupported curve")
+	}
+
+	priv, err := ecdhCurve.NewPrivateKey(k)
+	if err != nil {
+		return nil, err
+	}
+
+	// the uncompressed point is 0x04 || x || y
+	point := priv.PublicKey().Bytes()
+	return new(big.Int).SetBytes(point[1 : 1+len(k)]), nil
+}
+
+// blindedInverse returns k^-1 mod q as b × (k × b)^-1 for a random b, so that the variable-time
+// inversion of math/big does not operate on the secret nonce.
+func blindedInverse(k, q *big.Int) (*big.Int, error) {
+	b, err := rand.Int(rand.Reader, new(big.Int).Sub(q, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	b.Add(b, big.NewInt(1))
+
+	kb := new(big.Int).Mul(k, b)
+	kb.Mod(kb, q)
+
+	inv := kb.ModInverse(kb, q)
+	inv.Mul(inv, b)
+	return inv.Mod(inv, q), nil
+}
@@ -0,0 +1,262 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yakumioto/dipper/types"
+)
+
+func mustParseBigHex(t *testing.T, s string) *big.Int {
+	x, ok := new(big.Int).SetString(s, 16)
+	assert.True(t, ok, "invalid hex number: %s", s)
+	return x
+}
+
+func TestRFC6979Vectors(t *testing.T) {
+	// RFC 6979, Appendix A.2.5 to A.2.7, with SHA-256 and with the default hash of each curve.
+	// The first P-256 vector makes the nonce generation loop, and is taken from the tests of the Go
+	// standard library.
+	tcs := []struct {
+		algorithm types.Algorithm
+		curve     elliptic.Curve
+		hash      crypto.Hash
+		d, x, y   string
+		msg       string
+		r, s      string
+	}{
+		{
+			algorithm: types.EcdsaP256,
+			curve:     elliptic.P256(),
+			hash:      crypto.SHA256,
+			d:         "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721",
+			x:         "60FED4BA255A9D31C961EB74C6356D68C049B8923B61FA6CE669622E60F29FB6",
+			y:         "7903FE1008B8BC99A41AE9E95628BC64F2F1B20C2D7E9F5177A3C294D4462299",
+			msg:       "wv[vnX",
+			r:         "EFD9073B652E76DA1B5A019C0E4A2E3FA529B035A6ABB91EF67F0ED7A1F21234",
+			s:         "3DB4706C9D9F4A4FE13BB5E08EF0FAB53A57DBAB2061C83A35FA411C68D2BA33",
+		},
+		{
+			algorithm: types.EcdsaP256,
+			curve:     elliptic.P256(),
+			hash:      crypto.SHA256,
+			d:         "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721",
+			x:         "60FED4BA255A9D31C961EB74C6356D68C049B8923B61FA6CE669622E60F29FB6",
+			y:         "7903FE1008B8BC99A41AE9E95628BC64F2F1B20C2D7E9F5177A3C294D4462299",
+			msg:       "sample",
+			r:         "EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716",
+			s:         "F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8",
+		},
+		{
+			algorithm: types.EcdsaP256,
+			curve:     elliptic.P256(),
+			hash:      crypto.SHA256,
+			d:         "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721",
+			x:         "60FED4BA255A9D31C961EB74C6356D68C049B8923B61FA6CE669622E60F29FB6",
+			y:         "7903FE1008B8BC99A41AE9E95628BC64F2F1B20C2D7E9F5177A3C294D4462299",
+			msg:       "test",
+			r:         "F1ABB023518351CD71D881567B1EA663ED3EFCF6C5132B354F28D3B0B7D38367",
+			s:         "019F4113742A2B14BD25926B49C649155F267E60D3814B4C0CC84250E46F0083",
+		},
+		{
+			algorithm: types.EcdsaP384,
+			curve:     elliptic.P384(),
+			hash:      crypto.SHA256,
+			d:         "6B9D3DAD2E1B8C1C05B19875B6659F4DE23C3B667BF297BA9AA47740787137D896D5724E4C70A825F872C9EA60D2EDF5",
+			x:         "EC3A4E415B4E19A4568618029F427FA5DA9A8BC4AE92E02E06AAE5286B300C64DEF8F0EA9055866064A254515480BC13",
+			y:         "8015D9B72D7D57244EA8EF9AC0C621896708A59367F9DFB9F54CA84B3F1C9DB1288B231C3AE0D4FE7344FD2533264720",
+			msg:       "sample",
+			r:         "21B13D1E013C7FA1392D03C5F99AF8B30C570C6F98D4EA8E354B63A21D3DAA33BDE1E888E63355D92FA2B3C36D8FB2CD",
+			s:         "F3AA443FB107745BF4BD77CB3891674632068A10CA67E3D45DB2266FA7D1FEEBEFDC63ECCD1AC42EC0CB8668A4FA0AB0",
+		},
+		{
+			algorithm: types.EcdsaP384,
+			curve:     elliptic.P384(),
+			hash:      crypto.SHA256,
+			d:         "6B9D3DAD2E1B8C1C05B19875B6659F4DE23C3B667BF297BA9AA47740787137D896D5724E4C70A825F872C9EA60D2EDF5",
+			x:         "EC3A4E415B4E19A4568618029F427FA5DA9A8BC4AE92E02E06AAE5286B300C64DEF8F0EA9055866064A254515480BC13",
+			y:         "8015D9B72D7D57244EA8EF9AC0C621896708A59367F9DFB9F54CA84B3F1C9DB1288B231C3AE0D4FE7344FD2533264720",
+			msg:       "test",
+			r:         "6D6DEFAC9AB64DABAFE36C6BF510352A4CC27001263638E5B16D9BB51D451559F918EEDAF2293BE5B475CC8F0188636B",
+			s:         "2D46F3BECBCC523D5F1A1256BF0C9B024D879BA9E838144C8BA6BAEB4B53B47D51AB373F9845C0514EEFB14024787265",
+		},
+		{
+			algorithm: types.EcdsaP521,
+			curve:     elliptic.P521(),
+			hash:      crypto.SHA256,
+			d:         "0FAD06DAA62BA3B25D2FB40133DA757205DE67F5BB0018FEE8C86E1B68C7E75CAA896EB32F1F47C70855836A6D16FCC1466F6D8FBEC67DB89EC0C08B0E996B83538",
+			x:         "1894550D0785932E00EAA23B694F213F8C3121F86DC97A04E5A7167DB4E5BCD371123D46E45DB6B5D5370A7F20FB633155D38FFA16D2BD761DCAC474B9A2F5023A4",
+			y:         "0493101C962CD4D2FDDF782285E64584139C2F91B47F87FF82354D6630F746A28A0DB25741B5B34A828008B22ACC23F924FAAFBD4D33F81EA66956DFEAA2BFDFCF5",
+			msg:       "sample",
+			r:         "1511BB4D675114FE266FC4372B87682BAECC01D3CC62CF2303C92B3526012659D16876E25C7C1E57648F23B73564D67F61C6F14D527D54972810421E7D87589E1A7",
+			s:         "04A171143A83163D6DF460AAF61522695F207A58B95C0644D87E52AA1A347916E4F7A72930B1BC06DBE22CE3F58264AFD23704CBB63B29B931F7DE6C9D949A7ECFC",
+		},
+		{
+			algorithm: types.EcdsaP521,
+			curve:     elliptic.P521(),
+			hash:      crypto.SHA256,
+			d:         "0FAD06DAA62BA3B25D2FB40133DA757205DE67F5BB0018FEE8C86E1B68C7E75CAA896EB32F1F47C70855836A6D16FCC1466F6D8FBEC67DB89EC0C08B0E996B83538",
+			x:         "1894550D0785932E00EAA23B694F213F8C3121F86DC97A04E5A7167DB4E5BCD371123D46E45DB6B5D5370A7F20FB633155D38FFA16D2BD761DCAC474B9A2F5023A4",
+			y:         "0493101C962CD4D2FDDF782285E64584139C2F91B47F87FF82354D6630F746A28A0DB25741B5B34A828008B22ACC23F924FAAFBD4D33F81EA66956DFEAA2BFDFCF5",
+			msg:       "test",
+			r:         "00E871C4A14F993C6C7369501900C4BC1E9C7B0B4BA44E04868B30B41D8071042EB28C4C250411D0CE08CD197E4188EA4876F279F90B3D8D74A3C76E6F1E4656AA8",
+			s:         "0CD52DBAA33B063C3A6CD8058A1FB0A46A4754B034FCC644766CA14DA8CA5CA9FDE00E88C1AD60CCBA759025299079D7A427EC3CC5B619BFBC828E7769BCD694E86",
+		},
+		{
+			algorithm: types.EcdsaP384,
+			curve:     elliptic.P384(),
+			hash:      crypto.SHA384,
+			d:         "6B9D3DAD2E1B8C1C05B19875B6659F4DE23C3B667BF297BA9AA47740787137D896D5724E4C70A825F872C9EA60D2EDF5",
+			x:         "EC3A4E415B4E19A4568618029F427FA5DA9A8BC4AE92E02E06AAE5286B300C64DEF8F0EA9055866064A254515480BC13",
+			y:         "8015D9B72D7D57244EA8EF9AC0C621896708A59367F9DFB9F54CA84B3F1C9DB1288B231C3AE0D4FE7344FD2533264720",
+			msg:       "sample",
+			r:         "94EDBB92A5ECB8AAD4736E56C691916B3F88140666CE9FA73D64C4EA95AD133C81A648152E44ACF96E36DD1E80FABE46",
+			s:         "99EF4AEB15F178CEA1FE40DB2603138F130E740A19624526203B6351D0A3A94FA329C145786E679E7B82C71A38628AC8",
+		},
+		{
+			algorithm: types.EcdsaP384,
+			curve:     elliptic.P384(),
+			hash:      crypto.SHA384,
+			d:         "6B9D3DAD2E1B8C1C05B19875B6659F4DE23C3B667BF297BA9AA47740787137D896D5724E4C70A825F872C9EA60D2EDF5",
+			x:         "EC3A4E415B4E19A4568618029F427FA5DA9A8BC4AE92E02E06AAE5286B300C64DEF8F0EA9055866064A254515480BC13",
+			y:         "8015D9B72D7D57244EA8EF9AC0C621896708A59367F9DFB9F54CA84B3F1C9DB1288B231C3AE0D4FE7344FD2533264720",
+			msg:       "test",
+			r:         "8203B63D3C853E8D77227FB377BCF7B7B772E97892A80F36AB775D509D7A5FEB0542A7F0812998DA8F1DD3CA3CF023DB",
+			s:         "DDD0760448D42D8A43AF45AF836FCE4DE8BE06B485E9B61B827C2F13173923E06A739F040649A667BF3B828246BAA5A5",
+		},
+		{
+			algorithm: types.EcdsaP521,
+			curve:     elliptic.P521(),
+			hash:      crypto.SHA512,
+			d:         "0FAD06DAA62BA3B25D2FB40133DA757205DE67F5BB0018FEE8C86E1B68C7E75CAA896EB32F1F47C70855836A6D16FCC1466F6D8FBEC67DB89EC0C08B0E996B83538",
+			x:         "1894550D0785932E00EAA23B694F213F8C3121F86DC97A04E5A7167DB4E5BCD371123D46E45DB6B5D5370A7F20FB633155D38FFA16D2BD761DCAC474B9A2F5023A4",
+			y:         "0493101C962CD4D2FDDF782285E64584139C2F91B47F87FF82354D6630F746A28A0DB25741B5B34A828008B22ACC23F924FAAFBD4D33F81EA66956DFEAA2BFDFCF5",
+			msg:       "sample",
+			r:         "0C328FAFCBD79DD77850370C46325D987CB525569FB63C5D3BC53950E6D4C5F174E25A1EE9017B5D450606ADD152B534931D7D4E8455CC91F9B15BF05EC36E377FA",
+			s:         "0617CCE7CF5064806C467F678D3B4080D6F1CC50AF26CA209417308281B68AF282623EAA63E5B5C0723D8B8C37FF0777B1A20F8CCB1DCCC43997F1EE0E44DA4A67A",
+		},
+		{
+			algorithm: types.EcdsaP521,
+			curve:     elliptic.P521(),
+			hash:      crypto.SHA512,
+			d:         "0FAD06DAA62BA3B25D2FB40133DA757205DE67F5BB0018FEE8C86E1B68C7E75CAA896EB32F1F47C70855836A6D16FCC1466F6D8FBEC67DB89EC0C08B0E996B83538",
+			x:         "1894550D0785932E00EAA23B694F213F8C3121F86DC97A04E5A7167DB4E5BCD371123D46E45DB6B5D5370A7F20FB633155D38FFA16D2BD761DCAC474B9A2F5023A4",
+			y:         "0493101C962CD4D2FDDF782285E64584139C2F91B47F87FF82354D6630F746A28A0DB25741B5B34A828008B22ACC23F924FAAFBD4D33F81EA66956DFEAA2BFDFCF5",
+			msg:       "test",
+			r:         "13E99020ABF5CEE7525D16B69B229652AB6BDF2AFFCAEF38773B4B7D08725F10CDB93482FDCC54EDCEE91ECA4166B2A7C6265EF0CE2BD7051B7CEF945BABD47EE6D",
+			s:         "1FBD0013C674AA79CB39849527916CE301C66EA7CE8B80682786AD60F98F7E78A19CA69EFF5C57400E3B3A0AD66CE0978214D13BAF4E9AC60752F7B155E2DE4DCE3",
+		},
+	}
+
+	for _, tc := range tcs {
+		priv := &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{Curve: tc.curve, X: mustParseBigHex(t, tc.x), Y: mustParseBigHex(t, tc.y)},
+			D:         mustParseBigHex(t, tc.d),
+		}
+
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		assert.NoErrorf(t, err, "MarshalPKCS8PrivateKey failed: %s", err)
+
+		privKey, err := new(KeyImportImpl[string]).KeyImport(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+			tc.algorithm, WithHash[string](tc.hash), WithDeterministicNonce[string]())
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		signature, err := privKey.Sign(tc.msg)
+		assert.NoErrorf(t, err, "Sign failed: %s", err)
+
+		again, err := privKey.Sign(tc.msg)
+		assert.NoErrorf(t, err, "Sign failed: %s", err)
+		assert.Equal(t, signature, again, "Deterministic signatures should be equal")
+
+		if tc.hash == defaultHash(tc.algorithm) {
+			defaultKey, err := new(KeyImportImpl[string]).KeyImport(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+				tc.algorithm, WithDeterministicNonce[string]())
+			assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+			again, err = defaultKey.Sign(tc.msg)
+			assert.NoErrorf(t, err, "Sign failed: %s", err)
+			assert.Equal(t, signature, again, "%s should hash with %s by default", tc.algorithm, tc.hash)
+		}
+
+		sig, err := base64.RawStdEncoding.DecodeString(signature[strings.LastIndex(signature, ".")+1:])
+		assert.NoErrorf(t, err, "DecodeString failed: %s", err)
+
+		var rs struct{ R, S *big.Int }
+		_, err = asn1.Unmarshal(sig, &rs)
+		assert.NoErrorf(t, err, "Unmarshal failed: %s", err)
+		assert.Equal(t, mustParseBigHex(t, tc.r), rs.R, "unexpected r for %s %q", tc.algorithm, tc.msg)
+		assert.Equal(t, mustParseBigHex(t, tc.s), rs.S, "unexpected s for %s %q", tc.algorithm, tc.msg)
+
+		pubKey, err := privKey.PublicKey()
+		assert.NoErrorf(t, err, "PublicKey failed: %s", err)
+
+		verified, err := pubKey.Verify(tc.msg, signature)
+		assert.NoErrorf(t, err, "Verify failed: %s", err)
+		assert.True(t, verified, "Verify failed")
+
+		privKey, err = new(KeyImportImpl[string]).KeyImport(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+			tc.algorithm, WithHash[string](tc.hash), WithDeterministicNonce[string](), WithP1363Encoding[string]())
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		signature, err = privKey.Sign(tc.msg)
+		assert.NoErrorf(t, err, "Sign failed: %s", err)
+
+		sig, err = base64.RawStdEncoding.DecodeString(signature[strings.LastIndex(signature, ".")+1:])
+		assert.NoErrorf(t, err, "DecodeString failed: %s", err)
+
+		size := scalarSize(tc.curve)
+		assert.Equal(t, strings.ToLower(fmt.Sprintf("%0*s%0*s", 2*size, tc.r, 2*size, tc.s)), hex.EncodeToString(sig),
+			"unexpected p1363 signature for %s %q", tc.algorithm, tc.msg)
+	}
+}
+
+func TestHedgedNonce(t *testing.T) {
+	for _, alg := range []types.Algorithm{types.EcdsaP256, types.EcdsaP384, types.EcdsaP521} {
+		privKey, err := new(KeyGeneratorImpl[string]).KeyGen(alg, WithHedgedNonce[string]())
+		assert.NoErrorf(t, err, "KeyGen failed: %s", err)
+
+		signature, err := privKey.Sign("hello world")
+		assert.NoErrorf(t, err, "Sign failed: %s", err)
+
+		again, err := privKey.Sign("hello world")
+		assert.NoErrorf(t, err, "Sign failed: %s", err)
+		assert.NotEqual(t, signature, again, "Hedged signatures should be randomized")
+
+		pubKey, err := privKey.PublicKey()
+		assert.NoErrorf(t, err, "PublicKey failed: %s", err)
+
+		for _, sig := range []string{signature, again} {
+			verified, err := pubKey.Verify("hello world", sig)
+			assert.NoErrorf(t, err, "Verify failed: %s", err)
+			assert.True(t, verified, "Verify failed")
+		}
+
+		pubKeyStr, err := pubKey.Export()
+		assert.NoErrorf(t, err, "Export failed: %s", err)
+
+		_, err = new(KeyImportImpl[string]).KeyImport(pubKeyStr, alg, WithHedgedNonce[string]())
+		assert.Error(t, err, "KeyImport public key with nonce option should fail")
+	}
+}
+
+func TestBlindedInverse(t *testing.T) {
+	q := elliptic.P256().Params().N
+
+	for _, k := range []*big.Int{big.NewInt(1), big.NewInt(2), new(big.Int).Sub(q, big.NewInt(1)), mustParseBigHex(t, "A6E3C57DD01ABE90086538398355DD4C3B17AA873382B0F24D6129493D8AAD60")} {
+		inv, err := blindedInverse(k, q)
+		assert.NoErrorf(t, err, "blindedInverse failed: %s", err)
+		assert.Equalf(t, new(big.Int).ModInverse(k, q), inv, "wrong inverse of %x", k)
+	}
+}
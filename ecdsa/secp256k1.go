@@ -0,0 +1,370 @@
+package ecdsa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secp256k1ecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+// recoverableSignatureSize is the length of an r||s||v signature.
+const recoverableSignatureSize = 65
+
+var (
+	oidPublicKeyECDSA      = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidNamedCurveSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+)
+
+// WithKeccak256 makes the key hash messages with the legacy Keccak-256 used by Ethereum, which
+// differs from SHA3-256 in its padding. Keccak-256 has no crypto.Hash value, so SignDigest and
+// VerifyDigest take 0 as the hash of Keccak-256 digests.
+func WithKeccak256[T types.DataType]() key.Option[T] {
+	return withHash[T](hashFunc{keccak: true})
+}
+
+// WithRecoverableSignature makes Sign emit and Verify accept Ethereum-style recoverable signatures,
+// the 65-byte concatenation r||s||v, where v is the recovery id 0 or 1 from which RecoverPublicKey
+// finds the public key of the signer. Verify also accepts v as 27 or 28. It is only supported by
+// ecdsa_secp256k1 keys, and takes precedence over WithP1363Encoding.
+func WithRecoverableSignature[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		if k.Algorithm() != types.EcdsaSecp256k1 {
+			return fmt.Errorf("ecdsa: recoverable signatures are not supported by %s", k.Algorithm())
+		}
+
+		switch k := k.(type) {
+		case *PrivateKey[T]:
+			k.recoverable = true
+		case *PublicKey[T]:
+			k.recoverable = true
+		default:
+			return errors.New("ecdsa: invalid key type")
+		}
+		return nil
+	}
+}
+
+// RecoverPublicKey returns the public key that made a recoverable ecdsa_secp256k1 signature of msg.
+// The options are applied to the returned key before msg is hashed, so that signatures of
+// Keccak-256 digests are recovered with WithKeccak256. The signature is verified by the recovered
+// key before it is returned.
+func RecoverPublicKey[T types.DataType](msg, signature T, opts ...key.Option[T]) (key.Key[T], error) {
+	k := &PublicKey[T]{
+		algorithm:   types.EcdsaSecp256k1,
+		hash:        hashFunc{hash: defaultHash(types.EcdsaSecp256k1)},
+		recoverable: true,
+	}
+
+	for _, opt := range opts {
+		if err := opt(k); err != nil {
+			return nil, err
+		}
+	}
+
+	parts := strings.SplitN(utils.ToString(signature), ".", 3)
+	if len(parts) != 3 {
+		return nil, errors.New("ecdsa: invalid signature data structure")
+	}
+
+	if parts[0] != k.algorithm {
+		return nil, fmt.Errorf("ecdsa: invalid algorithm type: %s", parts[0])
+	}
+
+	providedSignature, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("ecdsa: decrypt provided signature failed to decode base64: %w", err)
+	}
+
+	h := k.hash.New()
+	if _, err = h.Write(utils.ToBytes(msg)); err != nil {
+		return nil, fmt.Errorf("ecdsa: failed to compute message : %w", err)
+	}
+
+	if k.publicKey, err = recoverSecp256k1(h.Sum(nil), providedSignature); err != nil {
+		return nil, fmt.Errorf("ecdsa: failed to recover public key: %w", err)
+	}
+
+	ok, err := k.Verify(msg, signature)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("ecdsa: invalid signature")
+	}
+
+	return k, nil
+}
+
+// hashFunc is the hash applied to messages: a crypto.Hash, or the legacy Keccak-256, which has no
+// crypto.Hash value.
+type hashFunc struct {
+	hash   crypto.Hash
+	keccak bool
+}
+
+// New returns a new hash.Hash computing h.
+func (h hashFunc) New() hash.Hash {
+	if h.keccak {
+		return sha3.NewLegacyKeccak256()
+	}
+	return h.hash.New()
+}
+
+// Size returns the digest length of h.
+func (h hashFunc) Size() int {
+	if h.keccak {
+		return 32
+	}
+	return h.hash.Size()
+}
+
+// CryptoHash returns the value identifying h in SignDigest and VerifyDigest, which is 0 for
+// Keccak-256.
+func (h hashFunc) CryptoHash() crypto.Hash {
+	if h.keccak {
+		return 0
+	}
+	return h.hash
+}
+
+func (h hashFunc) String() string {
+	if h.keccak {
+		return "Keccak-256"
+	}
+	return h.hash.String()
+}
+
+// isSecp256k1 reports whether curve is secp256k1.
+func isSecp256k1(curve elliptic.Curve) bool {
+	return curve == elliptic.Curve(secp256k1.S256())
+}
+
+// normalizeLowS rewrites an ASN.1 signature (r, s) as (r, n-s) when s is larger than n/2, so that
+// each message and key has a single valid signature, as required by Bitcoin and Ethereum.
+func normalizeLowS(curve elliptic.Curve, sig []byte) ([]byte, error) {
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(sig, &rs); err != nil {
+		return nil, err
+	}
+
+	if isLowS(curve, rs.S) {
+		return sig, nil
+	}
+
+	return asn1.Marshal(struct{ R, S *big.Int }{rs.R, new(big.Int).Sub(curve.Params().N, rs.S)})
+}
+
+// isLowS reports whether s is at most n/2.
+func isLowS(curve elliptic.Curve, s *big.Int) bool {
+	halfOrder := new(big.Int).Rsh(curve.Params().N, 1)
+	return s.Cmp(halfOrder) <= 0
+}
+
+// asn1ToRecoverable converts an ASN.1 signature of digest made by pub into r||s||v.
+func asn1ToRecoverable(pub *ecdsa.PublicKey, digest, sig []byte) ([]byte, error) {
+	rs, err := asn1ToP1363(pub.Curve, sig)
+	if err != nil {
+		return nil, err
+	}
+
+	for v := byte(0); v < 4; v++ {
+		recovered, err := recoverSecp256k1(digest, append(rs, v))
+		if err == nil && recovered.X.Cmp(pub.X) == 0 && recovered.Y.Cmp(pub.Y) == 0 {
+			return append(rs, v), nil
+		}
+	}
+
+	return nil, errors.New("failed to compute recovery id")
+}
+
+// recoverableToRS parses an r||s||v signature.
+func recoverableToRS(curve elliptic.Curve, sig []byte) (r, s *big.Int, err error) {
+	if len(sig) != recoverableSignatureSize {
+		return nil, nil, fmt.Errorf("invalid recoverable signature length: want %d bytes, got %d", recoverableSignatureSize, len(sig))
+	}
+
+	return p1363ToRS(curve, sig[:recoverableSignatureSize-1])
+}
+
+// recoverSecp256k1 returns the public key that made an r||s||v signature of digest.
+func recoverSecp256k1(digest, sig []byte) (*ecdsa.PublicKey, error) {
+	if len(sig) != recoverableSignatureSize {
+		return nil, fmt.Errorf("invalid recoverable signature length: want %d bytes, got %d", recoverableSignatureSize, len(sig))
+	}
+
+	v := sig[recoverableSignatureSize-1]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 3 {
+		return nil, fmt.Errorf("invalid recovery id: %d", sig[recoverableSignatureSize-1])
+	}
+
+	// the compact format of the secp256k1 package is 27+v||r||s
+	compact := append([]byte{27 + v}, sig[:recoverableSignatureSize-1]...)
+
+	pub, _, err := secp256k1ecdsa.RecoverCompact(compact, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return pub.ToECDSA(), nil
+}
+
+// ecPrivateKey is the SEC 1 structure of an EC private key.
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// pkcs8 is the PKCS #8 structure of a private key.
+type pkcs8 struct {
+	Version    int
+	Algo       pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// publicKeyInfo is the PKIX structure of a public key.
+type publicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// secp256k1AlgorithmIdentifier returns the PKIX algorithm of secp256k1 keys. The crypto/x509
+// package does not know secp256k1, so its keys are encoded here with the same structures and the
+// secp256k1 OID from SEC 2.
+func secp256k1AlgorithmIdentifier() (pkix.AlgorithmIdentifier, error) {
+	params, err := asn1.Marshal(oidNamedCurveSecp256k1)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+
+	return pkix.AlgorithmIdentifier{
+		Algorithm:  oidPublicKeyECDSA,
+		Parameters: asn1.RawValue{FullBytes: params},
+	}, nil
+}
+
+// secp256k1PublicKeyBytes returns the uncompressed encoding of pub.
+func secp256k1PublicKeyBytes(pub *ecdsa.PublicKey) []byte {
+	point := make([]byte, 65)
+	point[0] = 4
+	pub.X.FillBytes(point[1:33])
+	pub.Y.FillBytes(point[33:])
+	return point
+}
+
+func marshalSecp256k1SEC1(priv *ecdsa.PrivateKey, oid asn1.ObjectIdentifier) ([]byte, error) {
+	pub := secp256k1PublicKeyBytes(&priv.PublicKey)
+
+	return asn1.Marshal(ecPrivateKey{
+		Version:       1,
+		PrivateKey:    priv.D.FillBytes(make([]byte, 32)),
+		NamedCurveOID: oid,
+		PublicKey:     asn1.BitString{Bytes: pub, BitLength: 8 * len(pub)},
+	})
+}
+
+func marshalSecp256k1PKCS8(priv *ecdsa.PrivateKey) ([]byte, error) {
+	algo, err := secp256k1AlgorithmIdentifier()
+	if err != nil {
+		return nil, err
+	}
+
+	// the curve is named by the algorithm identifier, as crypto/x509 does for the NIST curves
+	sec1, err := marshalSecp256k1SEC1(priv, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(pkcs8{Algo: algo, PrivateKey: sec1})
+}
+
+func marshalSecp256k1PKIX(pub *ecdsa.PublicKey) ([]byte, error) {
+	algo, err := secp256k1AlgorithmIdentifier()
+	if err != nil {
+		return nil, err
+	}
+
+	point := secp256k1PublicKeyBytes(pub)
+
+	return asn1.Marshal(publicKeyInfo{
+		Algorithm: algo,
+		PublicKey: asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+	})
+}
+
+// parseSecp256k1Key parses a secp256k1 PKCS #8 or SEC 1 private key or PKIX public key.
+func parseSecp256k1Key(der []byte) (interface{}, error) {
+	var p8 pkcs8
+	if rest, err := asn1.Unmarshal(der, &p8); err == nil && len(rest) == 0 {
+		if !p8.Algo.Algorithm.Equal(oidPublicKeyECDSA) || !isSecp256k1Params(p8.Algo.Parameters) {
+			return nil, errors.New("not a secp256k1 private key")
+		}
+		return parseSecp256k1SEC1(p8.PrivateKey, false)
+	}
+
+	var info publicKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err == nil && len(rest) == 0 {
+		if !info.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) || !isSecp256k1Params(info.Algorithm.Parameters) {
+			return nil, errors.New("not a secp256k1 public key")
+		}
+
+		pub, err := secp256k1.ParsePubKey(info.PublicKey.RightAlign())
+		if err != nil {
+			return nil, err
+		}
+		return pub.ToECDSA(), nil
+	}
+
+	return parseSecp256k1SEC1(der, true)
+}
+
+func isSecp256k1Params(params asn1.RawValue) bool {
+	var oid asn1.ObjectIdentifier
+	rest, err := asn1.Unmarshal(params.FullBytes, &oid)
+	return err == nil && len(rest) == 0 && oid.Equal(oidNamedCurveSecp256k1)
+}
+
+func parseSecp256k1SEC1(der []byte, needOID bool) (*ecdsa.PrivateKey, error) {
+	var priv ecPrivateKey
+	rest, err := asn1.Unmarshal(der, &priv)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after private key")
+	}
+
+	if priv.Version != 1 {
+		return nil, fmt.Errorf("unknown EC private key version %d", priv.Version)
+	}
+
+	if (needOID || len(priv.NamedCurveOID) != 0) && !priv.NamedCurveOID.Equal(oidNamedCurveSecp256k1) {
+		return nil, errors.New("not a secp256k1 private key")
+	}
+
+	d := new(big.Int).SetBytes(priv.PrivateKey)
+	if d.Sign() == 0 || d.Cmp(secp256k1.S256().Params().N) >= 0 {
+		return nil, errors.New("invalid private key value")
+	}
+
+	return secp256k1.PrivKeyFromBytes(priv.PrivateKey).ToECDSA(), nil
+}
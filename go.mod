@@ -3,6 +3,7 @@ module github.com/yakumioto/dipper
 go 1.21.5
 
 require (
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0
 	github.com/stretchr/testify v1.9.0
 	golang.org/x/crypto v0.22.0
 )
@@ -2,6 +2,7 @@ package hmac
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/hmac"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -22,15 +23,44 @@ var (
 
 type ShaKeyImpl[T types.DataType] struct {
 	key           []byte
+	encoded       bool
 	algorithm     types.Algorithm
 	signatureFunc func() hash.Hash
 }
 
+// WithBase64Key decodes the imported key from unpadded base64, the encoding in which generated
+// keys are exported. The key is then exported as base64 again. Generated keys are already encoded,
+// so the option has no effect on them.
+func WithBase64Key[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		if sk, ok := k.(*ShaKeyImpl[T]); ok {
+			if sk.encoded {
+				return nil
+			}
+
+			decoded, err := base64.RawStdEncoding.DecodeString(string(sk.key))
+			if err != nil {
+				return fmt.Errorf("hmac-sha: failed to decode base64 key: %w", err)
+			}
+			if len(decoded) == 0 {
+				return errors.New("hmac-sha: empty key")
+			}
+
+			sk.key, sk.encoded = decoded, true
+			return nil
+		}
+		return errors.New("hmac-sha: invalid key type")
+	}
+}
+
 func (s *ShaKeyImpl[T]) Algorithm() types.Algorithm {
 	return s.algorithm
 }
 
 func (s *ShaKeyImpl[T]) Export() (T, error) {
+	if s.encoded {
+		return T(base64.RawStdEncoding.EncodeToString(s.key)), nil
+	}
 	return T(s.key), nil
 }
 
@@ -51,8 +81,19 @@ func (s *ShaKeyImpl[T]) Sign(msg T) (signature T, err error) {
 		return T(""), fmt.Errorf("hmac-sha: failed to write message bytes to hash: %w", err)
 	}
 
-	digest := h.Sum(nil)
+	return s.signDigest(h.Sum(nil)), nil
+}
+
+// SignDigest signs a SHA-256 digest, the hash applied to messages before computing the HMAC.
+func (s *ShaKeyImpl[T]) SignDigest(digest []byte, hash crypto.Hash) (T, error) {
+	if err := checkDigest(digest, hash); err != nil {
+		return T(""), err
+	}
+
+	return s.signDigest(digest), nil
+}
 
+func (s *ShaKeyImpl[T]) signDigest(digest []byte) T {
 	hc := hmac.New(s.signatureFunc, s.key)
 	hc.Write(digest)
 
@@ -63,10 +104,28 @@ func (s *ShaKeyImpl[T]) Sign(msg T) (signature T, err error) {
 	data.WriteString(".")
 	data.WriteString(base64.RawStdEncoding.EncodeToString(hc.Sum(nil)))
 
-	return T(data.Bytes()), nil
+	return T(data.Bytes())
 }
 
 func (s *ShaKeyImpl[T]) Verify(msg, signature T) (bool, error) {
+	h := sha256.New()
+	if _, err := h.Write(utils.ToBytes(msg)); err != nil {
+		return false, fmt.Errorf("hmac-sha: failed to compute message : %w", err)
+	}
+
+	return s.verifyDigest(h.Sum(nil), signature)
+}
+
+// VerifyDigest verifies a signature of a SHA-256 digest.
+func (s *ShaKeyImpl[T]) VerifyDigest(digest []byte, hash crypto.Hash, signature T) (bool, error) {
+	if err := checkDigest(digest, hash); err != nil {
+		return false, err
+	}
+
+	return s.verifyDigest(digest, signature)
+}
+
+func (s *ShaKeyImpl[T]) verifyDigest(digest []byte, signature T) (bool, error) {
 	dataBytes := utils.ToString(signature)
 
 	parts := strings.SplitN(dataBytes, ".", 3)
@@ -90,13 +149,6 @@ func (s *ShaKeyImpl[T]) Verify(msg, signature T) (bool, error) {
 		return false, fmt.Errorf("hmac-sha: decrypt provided signature failed to decode base64: %w", err)
 	}
 
-	h := sha256.New()
-	if _, err = h.Write(utils.ToBytes(msg)); err != nil {
-		return false, fmt.Errorf("hmac-sha: failed to compute message : %w", err)
-	}
-
-	digest := h.Sum(nil)
-
 	hc := hmac.New(s.signatureFunc, s.key)
 	hc.Write(digest)
 
@@ -115,6 +167,60 @@ func (s *ShaKeyImpl[T]) Decrypt(_ T) (T, error) {
 	return T(""), ErrUnsupportedMethod
 }
 
+// checkDigest reports an error if digest is not a SHA-256 digest.
+func checkDigest(digest []byte, hash crypto.Hash) error {
+	if hash != crypto.SHA256 {
+		return fmt.Errorf("hmac-sha: digest hash %v does not match key hash %v", hash, crypto.SHA256)
+	}
+
+	if len(digest) != hash.Size() {
+		return fmt.Errorf("hmac-sha: invalid digest length: want %d bytes, got %d", hash.Size(), len(digest))
+	}
+	return nil
+}
+
+func init() {
+	for _, alg := range []types.Algorithm{types.HmacSha256, types.HmacSha512} {
+		key.Register[string](alg, new(ShaKeyImportImpl[string]), new(ShaKeyGeneratorImpl[string]))
+		key.Register[[]byte](alg, new(ShaKeyImportImpl[[]byte]), new(ShaKeyGeneratorImpl[[]byte]))
+	}
+}
+
+// ShaKeyGeneratorImpl generates random HMAC keys of the hash output size. The generated key is
+// exported as a base64 string and can be imported again with KeyImport and WithBase64Key.
+type ShaKeyGeneratorImpl[T types.DataType] struct{}
+
+func (h *ShaKeyGeneratorImpl[T]) KeyGen(alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
+	var size int
+	switch alg {
+	case types.HmacSha256:
+		size = sha256.Size
+	case types.HmacSha512:
+		size = sha512.Size
+	default:
+		return nil, fmt.Errorf("hmac-sha: unsupported algorithm: %v", alg)
+	}
+
+	keyBytes, err := utils.RandomSize(size)
+	if err != nil {
+		return nil, fmt.Errorf("hmac-sha: failed to generate random key: %w", err)
+	}
+
+	k, err := newShaKeyImpl[T](keyBytes, alg)
+	if err != nil {
+		return nil, err
+	}
+	k.encoded = true
+
+	for _, opt := range opts {
+		if err = opt(k); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
 type ShaKeyImportImpl[T types.DataType] struct{}
 
 func (h *ShaKeyImportImpl[T]) KeyImport(raw interface{}, alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
@@ -123,6 +229,21 @@ func (h *ShaKeyImportImpl[T]) KeyImport(raw interface{}, alg types.Algorithm, op
 		return nil, err
 	}
 
+	k, err := newShaKeyImpl[T](keyBytes, alg)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		if err = opt(k); err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+func newShaKeyImpl[T types.DataType](keyBytes []byte, alg types.Algorithm) (*ShaKeyImpl[T], error) {
 	switch alg {
 	case types.HmacSha256:
 		return &ShaKeyImpl[T]{
@@ -1,10 +1,14 @@
 package hmac
 
 import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/yakumioto/dipper/key"
 	"github.com/yakumioto/dipper/types"
 )
 
@@ -134,3 +138,78 @@ func TestHmacShaSignAndVerify(t *testing.T) {
 		assert.True(t, plaintext, "Verify failed")
 	}
 }
+
+func TestHmacShaSignAndVerifyDigest(t *testing.T) {
+	tcs := []struct {
+		algorithm types.Algorithm
+	}{
+		{
+			algorithm: types.HmacSha256,
+		},
+		{
+			algorithm: types.HmacSha512,
+		},
+	}
+
+	digest := sha256.Sum256([]byte("hello world"))
+
+	for _, tc := range tcs {
+		ki := new(ShaKeyImportImpl[string])
+
+		k, err := ki.KeyImport("123456", tc.algorithm)
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		dk, ok := k.(key.DigestKey[string])
+		assert.True(t, ok, "key should implement DigestKey")
+
+		signature, err := dk.SignDigest(digest[:], crypto.SHA256)
+		assert.NoErrorf(t, err, "SignDigest failed: %s", err)
+
+		expected, err := k.Sign("hello world")
+		assert.NoErrorf(t, err, "Sign failed: %s", err)
+		assert.Equal(t, expected, signature, "SignDigest should match Sign")
+
+		ok, err = k.Verify("hello world", signature)
+		assert.NoErrorf(t, err, "Verify failed: %s", err)
+		assert.True(t, ok, "Verify failed")
+
+		ok, err = dk.VerifyDigest(digest[:], crypto.SHA256, expected)
+		assert.NoErrorf(t, err, "VerifyDigest failed: %s", err)
+		assert.True(t, ok, "VerifyDigest failed")
+
+		_, err = dk.SignDigest(digest[:], crypto.SHA512)
+		assert.Error(t, err, "SignDigest with another hash should fail")
+
+		_, err = dk.SignDigest(digest[:16], crypto.SHA256)
+		assert.Error(t, err, "SignDigest with a short digest should fail")
+
+		other := sha256.Sum256([]byte("hello dipper"))
+		_, err = dk.VerifyDigest(other[:], crypto.SHA256, signature)
+		assert.Error(t, err, "VerifyDigest of another digest should fail")
+	}
+}
+
+func TestBase64Key(t *testing.T) {
+	raw := []byte{0x00, 0x01, 0xfe, 0xff}
+	encoded := base64.RawStdEncoding.EncodeToString(raw)
+
+	ki := new(ShaKeyImportImpl[string])
+
+	k, err := ki.KeyImport(encoded, types.HmacSha256, WithBase64Key[string]())
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+	rawKey, err := ki.KeyImport(string(raw), types.HmacSha256)
+	assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+	assert.Equal(t, rawKey.SKI(), k.SKI(), "WithBase64Key should decode the key")
+
+	exported, err := k.Export()
+	assert.NoErrorf(t, err, "Export failed: %s", err)
+	assert.Equal(t, encoded, exported, "Export should encode the key with base64")
+
+	_, err = ki.KeyImport("not base64!", types.HmacSha256, WithBase64Key[string]())
+	assert.Error(t, err, "KeyImport of invalid base64 should fail")
+
+	generated, err := new(ShaKeyGeneratorImpl[string]).KeyGen(types.HmacSha512)
+	assert.NoErrorf(t, err, "KeyGen failed: %s", err)
+	assert.Len(t, generated.(*ShaKeyImpl[string]).key, 64, "generated key should be raw bytes of the hash size")
+}
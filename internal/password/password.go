@@ -0,0 +1,181 @@
+// Package password implements the password mode of the aes and chacha20 packages, in which every
+// encryption derives a fresh key from a password with a random salt.
+package password
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yakumioto/dipper/argon2"
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+// Deriver returns the key deriver of kdf, which must be an Argon2 or PBKDF2 key created with
+// KeyGenerate. If kdf is nil, Argon2id with the default parameters of the argon2 package is used.
+func Deriver[T types.DataType](kdf key.Key[T]) (key.Deriver, error) {
+	if kdf == nil {
+		var err error
+		if kdf, err = new(argon2.KeyGeneratorImpl[T]).KeyGen(types.Argon2); err != nil {
+			return nil, fmt.Errorf("failed to create default password kdf: %w", err)
+		}
+	}
+
+	deriver, ok := kdf.(key.Deriver)
+	if !ok {
+		return nil, fmt.Errorf("%s cannot derive keys from a password", kdf.Algorithm())
+	}
+	return deriver, nil
+}
+
+// Cipher describes the cipher keyed with the keys derived from the password.
+type Cipher[T types.DataType] struct {
+	// Name prefixes the errors, such as "aes".
+	Name string
+	// KeySize is the size of the derived keys.
+	KeySize int
+	// NewKey returns the key of the algorithm using derivedKey as the cipher key. Its ciphertexts
+	// must have the format {algorithm}.{base64 payload}.
+	NewKey func(derivedKey []byte) (key.Key[T], error)
+	// ErrUnsupportedMethod is returned by the methods the password mode does not support.
+	ErrUnsupportedMethod error
+}
+
+// Key is a key derived from a password for every encryption.
+// The ciphertext format is {algorithm}.{kdf header}${base64 payload}, where the payload is the
+// same as that of the keys returned by Cipher.NewKey.
+type Key[T types.DataType] struct {
+	cipher    Cipher[T]
+	password  []byte
+	algorithm types.Algorithm
+	deriver   key.Deriver
+}
+
+// New returns the password mode key of alg, deriving the keys of cipher from password with deriver.
+func New[T types.DataType](cipher Cipher[T], alg types.Algorithm, password []byte, deriver key.Deriver) *Key[T] {
+	return &Key[T]{cipher: cipher, password: password, algorithm: alg, deriver: deriver}
+}
+
+func (k *Key[T]) Algorithm() types.Algorithm {
+	return k.algorithm
+}
+
+func (k *Key[T]) Export() (key T, err error) {
+	return T(k.password), nil
+}
+
+func (k *Key[T]) SKI() T {
+	sha := sha256.New()
+	sha.Write(k.password)
+
+	return T(utils.ToHexString(sha.Sum(nil)))
+}
+
+func (k *Key[T]) PublicKey() (key.Key[T], error) {
+	return nil, k.cipher.ErrUnsupportedMethod
+}
+
+func (k *Key[T]) Sign(_ T) (T, error) {
+	return T(""), k.cipher.ErrUnsupportedMethod
+}
+
+func (k *Key[T]) Verify(_, _ T) (bool, error) {
+	return false, k.cipher.ErrUnsupportedMethod
+}
+
+func (k *Key[T]) Encrypt(plaintext T) (T, error) {
+	return k.seal(func(dk key.Key[T]) (T, error) {
+		return dk.Encrypt(plaintext)
+	})
+}
+
+func (k *Key[T]) EncryptWithAAD(plaintext, additionalData T) (T, error) {
+	return k.seal(func(dk key.Key[T]) (T, error) {
+		aead, ok := dk.(key.AEADKey[T])
+		if !ok {
+			return T(""), k.cipher.ErrUnsupportedMethod
+		}
+		return aead.EncryptWithAAD(plaintext, additionalData)
+	})
+}
+
+func (k *Key[T]) Decrypt(ciphertext T) (T, error) {
+	return k.open(ciphertext, func(dk key.Key[T], ciphertext T) (T, error) {
+		return dk.Decrypt(ciphertext)
+	})
+}
+
+func (k *Key[T]) DecryptWithAAD(ciphertext, additionalData T) (T, error) {
+	return k.open(ciphertext, func(dk key.Key[T], ciphertext T) (T, error) {
+		aead, ok := dk.(key.AEADKey[T])
+		if !ok {
+			return T(""), k.cipher.ErrUnsupportedMethod
+		}
+		return aead.DecryptWithAAD(ciphertext, additionalData)
+	})
+}
+
+func (k *Key[T]) seal(encrypt func(dk key.Key[T]) (T, error)) (T, error) {
+	derivedKey, header, err := k.deriver.DeriveKey(k.password, k.cipher.KeySize)
+	if err != nil {
+		return T(""), fmt.Errorf("%s: failed to derive key from password: %w", k.cipher.Name, err)
+	}
+
+	dk, err := k.cipher.NewKey(derivedKey)
+	if err != nil {
+		return T(""), err
+	}
+
+	ciphertext, err := encrypt(dk)
+	if err != nil {
+		return T(""), err
+	}
+
+	_, payload, _ := strings.Cut(utils.ToString(ciphertext), ".")
+
+	data := bytes.NewBuffer(nil)
+	data.WriteString(k.algorithm)
+	data.WriteString(".")
+	data.WriteString(header)
+	data.WriteString("$")
+	data.WriteString(payload)
+
+	return T(data.Bytes()), nil
+}
+
+func (k *Key[T]) open(ciphertext T, decrypt func(dk key.Key[T], ciphertext T) (T, error)) (T, error) {
+	dataBytes := utils.ToString(ciphertext)
+	parts := strings.SplitN(dataBytes, ".", 2)
+	if len(parts) != 2 {
+		return T(""), fmt.Errorf("%s: invalid encrypted data structure", k.cipher.Name)
+	}
+
+	algorithm, payload := parts[0], parts[1]
+
+	if algorithm != k.algorithm {
+		return T(""), fmt.Errorf("%s: invalid algorithm type: %s", k.cipher.Name, algorithm)
+	}
+
+	i := strings.LastIndex(payload, "$")
+	if i < 0 {
+		return T(""), errors.New(k.cipher.Name + ": missing password kdf header")
+	}
+
+	header, payload := payload[:i], payload[i+1:]
+
+	derivedKey, err := k.deriver.DeriveKeyFromHeader(k.password, header, k.cipher.KeySize)
+	if err != nil {
+		return T(""), fmt.Errorf("%s: failed to derive key from password: %w", k.cipher.Name, err)
+	}
+
+	dk, err := k.cipher.NewKey(derivedKey)
+	if err != nil {
+		return T(""), err
+	}
+
+	return decrypt(dk, T(k.algorithm+"."+payload))
+}
@@ -0,0 +1,68 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yakumioto/dipper/aes"
+	"github.com/yakumioto/dipper/chacha20"
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/pbkdf2"
+	"github.com/yakumioto/dipper/types"
+)
+
+func TestEnvelope(t *testing.T) {
+	kdf, err := new(pbkdf2.KeyGeneratorImpl[string]).KeyGen(types.Pbkdf2Sha256, pbkdf2.WithIterations[string](20000))
+	assert.NoErrorf(t, err, "KeyGen failed: %s", err)
+
+	tcs := []struct {
+		name      string
+		algorithm types.Algorithm
+		importer  key.Importer[string]
+		opt       key.Option[string]
+	}{
+		{
+			name:      "aes",
+			algorithm: types.AesGcm256,
+			importer:  new(aes.KeyImportImpl[string]),
+			opt:       aes.WithPassword[string](kdf),
+		},
+		{
+			name:      "chacha20",
+			algorithm: types.XChacha20Poly1305,
+			importer:  new(chacha20.KeyImportImpl[string]),
+			opt:       chacha20.WithPassword[string](kdf),
+		},
+	}
+
+	for _, tc := range tcs {
+		k, err := tc.importer.KeyImport("123456", tc.algorithm, tc.opt)
+		assert.NoErrorf(t, err, "KeyImport failed: %s", err)
+
+		ciphertext, err := k.Encrypt("hello world")
+		assert.NoErrorf(t, err, "Encrypt failed: %s", err)
+
+		envelope := strings.TrimPrefix(ciphertext, tc.algorithm+".")
+		i := strings.LastIndex(envelope, "$")
+		assert.Positive(t, i, "ciphertext should have a kdf header")
+
+		header, payload := envelope[:i], envelope[i+1:]
+		assert.True(t, strings.HasPrefix(header, "pbkdf2_sha256$20000$"), "wrong kdf header")
+		assert.NotContains(t, payload, ".", "wrong payload")
+
+		plaintext, err := k.Decrypt(ciphertext)
+		assert.NoErrorf(t, err, "Decrypt failed: %s", err)
+		assert.Equal(t, "hello world", plaintext, "Decrypt failed")
+
+		_, err = k.Decrypt(tc.algorithm + "." + payload)
+		assert.EqualError(t, err, tc.name+": missing password kdf header", "Decrypt without header should fail")
+
+		_, err = k.Decrypt("other." + envelope)
+		assert.EqualError(t, err, tc.name+": invalid algorithm type: other", "Decrypt of another algorithm should fail")
+
+		_, err = k.Sign("hello world")
+		assert.Error(t, err, "Sign should fail")
+	}
+}
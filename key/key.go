@@ -1,6 +1,11 @@
 package key
 
-import "github.com/yakumioto/dipper/types"
+import (
+	"crypto"
+	"io"
+
+	"github.com/yakumioto/dipper/types"
+)
 
 // Key is an interface that represents a cryptographic key.
 // It provides methods for getting the algorithm type, byte representation, subject key identifier (SKI),
@@ -16,6 +21,42 @@ type Key[T types.DataType] interface {
 	Decrypt(ciphertext T) (plaintext T, err error)
 }
 
+// AEADKey is an interface that represents a symmetric key supporting authenticated encryption
+// with associated data. The associated data is authenticated but not encrypted, and the same
+// associated data must be provided to decrypt the ciphertext.
+type AEADKey[T types.DataType] interface {
+	Key[T]
+	EncryptWithAAD(plaintext, additionalData T) (ciphertext T, err error)
+	DecryptWithAAD(ciphertext, additionalData T) (plaintext T, err error)
+}
+
+// DigestKey is an interface that represents a key able to sign and verify digests computed
+// elsewhere, such as the hash of a large file computed while streaming it. The hash identifies the
+// function that produced the digest and must be the one the key uses for messages. Signatures have
+// the same format as those of Sign, so that Verify accepts signatures made by SignDigest and
+// VerifyDigest accepts signatures made by Sign.
+type DigestKey[T types.DataType] interface {
+	Key[T]
+	SignDigest(digest []byte, hash crypto.Hash) (signature T, err error)
+	VerifyDigest(digest []byte, hash crypto.Hash, signature T) (bool, error)
+}
+
+// StreamingKey is an interface that represents a symmetric key able to encrypt data streams of any
+// length in fixed-size authenticated segments, without holding the whole plaintext in memory.
+// The encrypted stream starts with a header naming the algorithm.
+type StreamingKey interface {
+	NewEncryptingWriter(w io.Writer) (io.WriteCloser, error)
+	NewDecryptingReader(r io.Reader) (io.Reader, error)
+}
+
+// Deriver is an interface that represents a password-based key derivation function.
+// DeriveKey derives a key from a password with a random salt and returns a header encoding the salt
+// and parameters, from which DeriveKeyFromHeader derives the same key again.
+type Deriver interface {
+	DeriveKey(password []byte, keyLen int) (derivedKey []byte, header string, err error)
+	DeriveKeyFromHeader(password []byte, header string, keyLen int) (derivedKey []byte, err error)
+}
+
 // Option is a function type that represents an option for a key.
 type Option[T types.DataType] func(Key[T]) error
 
@@ -0,0 +1,89 @@
+package key
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/yakumioto/dipper/types"
+)
+
+type registration struct {
+	importer  any
+	generator any
+}
+
+type registryKey struct {
+	dataType  reflect.Type
+	algorithm types.Algorithm
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[registryKey]registration)
+)
+
+// Register makes an algorithm available to dipper.KeyImport and dipper.KeyGenerate for the data
+// type T. Either importer or generator may be nil if the algorithm does not support it.
+// Packages usually call Register from an init function, once for string and once for []byte.
+// dipper.KeyImport and dipper.KeyGenerate instantiate the built-in algorithms again for named data
+// types, while other algorithms must be registered for every data type they are used with.
+// Register panics if the algorithm is already registered for T or both importer and generator are nil.
+func Register[T types.DataType](alg types.Algorithm, importer Importer[T], generator Generator[T]) {
+	if importer == nil && generator == nil {
+		panic(fmt.Sprintf("key: Register %s without importer and generator", alg))
+	}
+
+	rk := registryKey{dataType: dataTypeOf[T](), algorithm: alg}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, dup := registry[rk]; dup {
+		panic(fmt.Sprintf("key: Register called twice for %s (%s)", alg, rk.dataType))
+	}
+
+	registry[rk] = registration{importer: importer, generator: generator}
+}
+
+// LookupImporter returns the importer registered for the algorithm and the data type T.
+func LookupImporter[T types.DataType](alg types.Algorithm) (Importer[T], bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	importer, ok := registry[registryKey{dataType: dataTypeOf[T](), algorithm: alg}].importer.(Importer[T])
+	return importer, ok
+}
+
+// LookupGenerator returns the generator registered for the algorithm and the data type T.
+func LookupGenerator[T types.DataType](alg types.Algorithm) (Generator[T], bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	generator, ok := registry[registryKey{dataType: dataTypeOf[T](), algorithm: alg}].generator.(Generator[T])
+	return generator, ok
+}
+
+// Algorithms returns the sorted names of all registered algorithms.
+func Algorithms() []types.Algorithm {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	seen := make(map[types.Algorithm]struct{})
+	algorithms := make([]types.Algorithm, 0, len(registry))
+	for rk := range registry {
+		if _, ok := seen[rk.algorithm]; ok {
+			continue
+		}
+		seen[rk.algorithm] = struct{}{}
+		algorithms = append(algorithms, rk.algorithm)
+	}
+	sort.Strings(algorithms)
+
+	return algorithms
+}
+
+func dataTypeOf[T types.DataType]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
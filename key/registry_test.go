@@ -0,0 +1,67 @@
+package key
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/yakumioto/dipper/types"
+)
+
+type testImporter[T types.DataType] struct{}
+
+func (t *testImporter[T]) KeyImport(_ interface{}, _ types.Algorithm, _ ...Option[T]) (Key[T], error) {
+	return nil, nil
+}
+
+type testGenerator[T types.DataType] struct{}
+
+func (t *testGenerator[T]) KeyGen(_ types.Algorithm, _ ...Option[T]) (Key[T], error) {
+	return nil, nil
+}
+
+// unregister removes a registration made by a test, so that the test can run again with -count.
+func unregister[T types.DataType](alg types.Algorithm) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	delete(registry, registryKey{dataType: dataTypeOf[T](), algorithm: alg})
+}
+
+func TestRegister(t *testing.T) {
+	Register[string]("test_registry_both", new(testImporter[string]), new(testGenerator[string]))
+	t.Cleanup(func() { unregister[string]("test_registry_both") })
+	Register[string]("test_registry_generator", nil, new(testGenerator[string]))
+	t.Cleanup(func() { unregister[string]("test_registry_generator") })
+
+	importer, ok := LookupImporter[string]("test_registry_both")
+	assert.True(t, ok, "LookupImporter failed")
+	assert.NotNil(t, importer, "LookupImporter failed")
+
+	generator, ok := LookupGenerator[string]("test_registry_both")
+	assert.True(t, ok, "LookupGenerator failed")
+	assert.NotNil(t, generator, "LookupGenerator failed")
+
+	_, ok = LookupImporter[[]byte]("test_registry_both")
+	assert.False(t, ok, "LookupImporter should be registered per data type")
+
+	_, ok = LookupImporter[string]("test_registry_generator")
+	assert.False(t, ok, "LookupImporter should fail without importer")
+
+	_, ok = LookupGenerator[string]("test_registry_generator")
+	assert.True(t, ok, "LookupGenerator failed")
+
+	_, ok = LookupImporter[string]("test_registry_unknown")
+	assert.False(t, ok, "LookupImporter should fail for unknown algorithms")
+
+	assert.Subset(t, Algorithms(), []types.Algorithm{"test_registry_both", "test_registry_generator"}, "Algorithms failed")
+	assert.IsIncreasing(t, Algorithms(), "Algorithms should be sorted")
+
+	assert.Panics(t, func() {
+		Register[string]("test_registry_both", new(testImporter[string]), nil)
+	}, "Register twice should panic")
+
+	assert.Panics(t, func() {
+		Register[string]("test_registry_none", nil, nil)
+	}, "Register without importer and generator should panic")
+}
@@ -33,12 +33,12 @@ type KeyImpl[T types.DataType] struct {
 
 func WithIterations[T types.DataType](iterations int) key.Option[T] {
 	return func(k key.Key[T]) error {
-		if _, ok := k.(*KeyImpl[T]); ok {
+		if k, ok := k.(*KeyImpl[T]); ok {
 			if iterations <= 0 {
 				return nil
 			}
 
-			k.(*KeyImpl[T]).iterations = iterations
+			k.iterations = iterations
 			return nil
 		}
 		return errors.New("pbkdf2: invalid key type")
@@ -47,12 +47,12 @@ func WithIterations[T types.DataType](iterations int) key.Option[T] {
 
 func WithSaltSize[T types.DataType](saltSize int) key.Option[T] {
 	return func(k key.Key[T]) error {
-		if _, ok := k.(*KeyImpl[T]); ok {
+		if k, ok := k.(*KeyImpl[T]); ok {
 			if saltSize <= 0 {
 				return nil
 			}
 
-			k.(*KeyImpl[T]).saltSize = saltSize
+			k.saltSize = saltSize
 			return nil
 		}
 
@@ -139,6 +139,55 @@ func (k *KeyImpl[T]) Verify(msg, signature T) (bool, error) {
 	return hmac.Equal(providedDigest, computedDigest), nil
 }
 
+func (k *KeyImpl[T]) DeriveKey(password []byte, keyLen int) ([]byte, string, error) {
+	saltBytes, err := utils.RandomSize(k.saltSize)
+	if err != nil {
+		return nil, "", fmt.Errorf("pbkdf2: failed to generate random salt: %w", err)
+	}
+
+	derivedKey := pbkdf2.Key(password, saltBytes, k.iterations, keyLen, k.digestFunc)
+
+	header := fmt.Sprintf("%s$%d$%s",
+		k.algorithm,
+		k.iterations,
+		base64.RawStdEncoding.EncodeToString(saltBytes),
+	)
+
+	return derivedKey, header, nil
+}
+
+// DeriveKeyFromHeader derives the key again from a header produced by DeriveKey. The iterations
+// in the header must not exceed those of k, so that a crafted header cannot make the derivation
+// arbitrarily expensive.
+func (k *KeyImpl[T]) DeriveKeyFromHeader(password []byte, header string, keyLen int) ([]byte, error) {
+	parts := strings.SplitN(header, "$", 3)
+	if len(parts) != 3 {
+		return nil, errors.New("pbkdf2: invalid header data structure")
+	}
+
+	algorithm, iterations, salt := parts[0], parts[1], parts[2]
+
+	if algorithm != k.algorithm {
+		return nil, fmt.Errorf("pbkdf2: invalid algorithm type: %s", algorithm)
+	}
+
+	providedIterations, err := strconv.Atoi(iterations)
+	if err != nil {
+		return nil, errors.New("pbkdf2: provided iterations is not a number")
+	}
+
+	if providedIterations <= 0 || providedIterations > k.iterations {
+		return nil, fmt.Errorf("pbkdf2: iterations out of range: %d", providedIterations)
+	}
+
+	providedSalt, err := base64.RawStdEncoding.DecodeString(salt)
+	if err != nil {
+		return nil, fmt.Errorf("pbkdf2: decrypt provided salt failed to decode base64: %w", err)
+	}
+
+	return pbkdf2.Key(password, providedSalt, providedIterations, keyLen, k.digestFunc), nil
+}
+
 func (k *KeyImpl[T]) Encrypt(_ T) (ciphertext T, err error) {
 	return T(""), ErrUnsupportedMethod
 }
@@ -147,6 +196,13 @@ func (k *KeyImpl[T]) Decrypt(_ T) (plaintext T, err error) {
 	return T(""), ErrUnsupportedMethod
 }
 
+func init() {
+	for _, alg := range []types.Algorithm{types.Pbkdf2Sha256, types.Pbkdf2Sha512} {
+		key.Register[string](alg, nil, new(KeyGeneratorImpl[string]))
+		key.Register[[]byte](alg, nil, new(KeyGeneratorImpl[[]byte]))
+	}
+}
+
 type KeyGeneratorImpl[T types.DataType] struct{}
 
 func (k *KeyGeneratorImpl[T]) KeyGen(alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
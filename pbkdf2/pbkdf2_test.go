@@ -5,6 +5,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/yakumioto/dipper/key"
 	"github.com/yakumioto/dipper/types"
 )
 
@@ -124,3 +125,27 @@ func TestSignAndVerify(t *testing.T) {
 
 	}
 }
+
+func TestDeriveKey(t *testing.T) {
+	kg := new(KeyGeneratorImpl[string])
+
+	k, err := kg.KeyGen(types.Pbkdf2Sha256, WithIterations[string](20000))
+	assert.NoErrorf(t, err, "KeyGen failed: %s", err)
+
+	deriver := k.(key.Deriver)
+
+	derivedKey, header, err := deriver.DeriveKey([]byte("123456"), 32)
+	assert.NoErrorf(t, err, "DeriveKey failed: %s", err)
+	assert.Len(t, derivedKey, 32, "DeriveKey failed")
+	assert.Contains(t, header, "pbkdf2_sha256$20000$", "DeriveKey failed")
+
+	rederivedKey, err := deriver.DeriveKeyFromHeader([]byte("123456"), header, 32)
+	assert.NoErrorf(t, err, "DeriveKeyFromHeader failed: %s", err)
+	assert.Equal(t, derivedKey, rederivedKey, "DeriveKeyFromHeader failed")
+
+	_, err = deriver.DeriveKeyFromHeader([]byte("123456"), "pbkdf2_sha256$100000000$c2FsdA", 32)
+	assert.Error(t, err, "DeriveKeyFromHeader with expensive params should fail")
+
+	_, err = deriver.DeriveKeyFromHeader([]byte("123456"), "pbkdf2_sha512$20000$c2FsdA", 32)
+	assert.Error(t, err, "DeriveKeyFromHeader with another algorithm should fail")
+}
@@ -0,0 +1,104 @@
+package rsa
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+)
+
+// Format is the structure used to export keys.
+type Format int
+
+const (
+	// FormatPKCS1 exports private keys in a PEM block labelled "RSA PRIVATE KEY" and public keys in
+	// a PEM block labelled "RSA PUBLIC KEY".
+	FormatPKCS1 Format = iota
+	// FormatPKCS8 exports private keys as PKCS #8, in a PEM block labelled "PRIVATE KEY".
+	FormatPKCS8
+	// FormatPKIX exports public keys as PKIX (SubjectPublicKeyInfo), in a PEM block labelled
+	// "PUBLIC KEY", the format written by `openssl rsa -pubout`.
+	FormatPKIX
+)
+
+// WithFormat sets the structure used by Export. Private keys support FormatPKCS1 and
+// FormatPKCS8, and public keys support FormatPKCS1 and FormatPKIX. The public key of a private
+// key exported as PKCS #8 is exported as PKIX.
+func WithFormat[T types.DataType](format Format) key.Option[T] {
+	return func(k key.Key[T]) error {
+		switch k := k.(type) {
+		case *PrivateKeyImpl[T]:
+			if format != FormatPKCS1 && format != FormatPKCS8 {
+				return fmt.Errorf("rsa: invalid private key format: %d", format)
+			}
+			k.format = format
+		case *PublicKeyImpl[T]:
+			if format != FormatPKCS1 && format != FormatPKIX {
+				return fmt.Errorf("rsa: invalid public key format: %d", format)
+			}
+			k.format = format
+		default:
+			return errors.New("rsa: invalid key type")
+		}
+		return nil
+	}
+}
+
+// WithDEREncoding makes Export return the raw DER bytes instead of a PEM block.
+func WithDEREncoding[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		switch k := k.(type) {
+		case *PrivateKeyImpl[T]:
+			k.der = true
+		case *PublicKeyImpl[T]:
+			k.der = true
+		default:
+			return errors.New("rsa: invalid key type")
+		}
+		return nil
+	}
+}
+
+// encode returns der as is, or wrapped in a PEM block with the given label.
+func encode(der []byte, label string, raw bool) []byte {
+	if raw {
+		return der
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: label, Bytes: der})
+}
+
+// parseKey parses a PKCS #1 or PKCS #8 private key or a PKCS #1 or PKIX public key, either PEM or
+// raw DER. The PEM label is not trusted, since earlier versions exported PKCS #1 public keys as
+// "PUBLIC KEY".
+func parseKey(keyBytes []byte) (interface{}, error) {
+	der := keyBytes
+	if block, _ := pem.Decode(keyBytes); block != nil {
+		der = block.Bytes
+	}
+
+	pk, pkcs1Err := x509.ParsePKCS1PrivateKey(der)
+	if pkcs1Err == nil {
+		return pk, nil
+	}
+
+	pk8, pkcs8Err := x509.ParsePKCS8PrivateKey(der)
+	if pkcs8Err == nil {
+		return pk8, nil
+	}
+
+	pub, pkcs1PubErr := x509.ParsePKCS1PublicKey(der)
+	if pkcs1PubErr == nil {
+		return pub, nil
+	}
+
+	pkix, pkixErr := x509.ParsePKIXPublicKey(der)
+	if pkixErr == nil {
+		return pkix, nil
+	}
+
+	return nil, fmt.Errorf("pkcs1 error: %w, pkcs8 error: %w, pkcs1 public key error: %w, pkix error: %w",
+		pkcs1Err, pkcs8Err, pkcs1PubErr, pkixErr)
+}
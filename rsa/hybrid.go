@@ -0,0 +1,91 @@
+package rsa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"errors"
+
+	"github.com/yakumioto/dipper/utils"
+)
+
+// dataKeySize is the length of the AES-256-GCM data key of hybrid ciphertexts.
+const dataKeySize = 32
+
+// errHybridDecryption is returned for any failure to decrypt a hybrid ciphertext.
+var errHybridDecryption = errors.New("rsa: decrypt error")
+
+// sealHybrid encrypts a plaintext too large for a single RSA block with a random AES-256-GCM data
+// key, which is itself encrypted with wrap. It returns the encrypted data key and
+// nonce||ciphertext, written as {algorithm}.{base64 encrypted data key}.{base64 nonce||ciphertext}.
+// The algorithm is authenticated as additional data.
+func sealHybrid(algorithm string, plaintext []byte, wrap func([]byte) ([]byte, error)) ([]byte, []byte, error) {
+	dataKey, err := utils.RandomSize(dataKeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrappedKey, err := wrap(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aead, err := newDataKeyAEAD(dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce, err := utils.RandomSize(aead.NonceSize())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return wrappedKey, aead.Seal(nonce, nonce, plaintext, []byte(algorithm)), nil
+}
+
+// openHybrid decrypts a ciphertext made by sealHybrid, decrypting the data key with unwrap.
+func openHybrid(algorithm string, wrappedKey, payload []byte, unwrap func([]byte) ([]byte, error)) ([]byte, error) {
+	dataKey, err := unwrap(wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dataKey) != dataKeySize {
+		return nil, errors.New("invalid data key size")
+	}
+
+	aead, err := newDataKeyAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	return aead.Open(nil, payload[:aead.NonceSize()], payload[aead.NonceSize():], []byte(algorithm))
+}
+
+// decryptPKCS1v15DataKey decrypts a data key encrypted with PKCS #1 v1.5 padding. Invalid padding
+// yields a random data key instead of an error, as recommended by RFC 5246, Section 7.4.7.1, so
+// that it cannot be told apart from a wrong data key by the error or the timing of the decryption.
+func (r *PrivateKeyImpl[T]) decryptPKCS1v15DataKey(wrappedKey []byte) ([]byte, error) {
+	dataKey, err := utils.RandomSize(dataKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rsa.DecryptPKCS1v15SessionKey(nil, r.privateKey, wrappedKey, dataKey); err != nil {
+		return nil, err
+	}
+	return dataKey, nil
+}
+
+func newDataKeyAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
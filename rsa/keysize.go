@@ -0,0 +1,97 @@
+package rsa
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+)
+
+// minKeySize is the smallest key size generated or imported as rsa without WithWeakKeySize.
+const minKeySize = 2048
+
+// publicExponent is the public exponent of the keys generated by crypto/rsa, and the only one
+// accepted by KeyImport.
+const publicExponent = 65537
+
+// WithKeySize sets the size in bits of the keys generated for the rsa algorithm, whose keys may
+// have any size. The default is 2048 bits. The other algorithms, such as rsa_3072, have a fixed key
+// size, which WithKeySize must match. Keys are always generated with the public exponent 65537,
+// the only one crypto/rsa generates and KeyImport accepts.
+func WithKeySize[T types.DataType](bits int) key.Option[T] {
+	return func(k key.Key[T]) error {
+		if r, ok := k.(*PrivateKeyImpl[T]); ok {
+			if r.privateKey != nil {
+				return errors.New("rsa: key size can only be set when generating a key")
+			}
+
+			if bits <= 0 {
+				return fmt.Errorf("rsa: invalid key size: %d", bits)
+			}
+
+			r.keySize = bits
+			return nil
+		}
+		return errors.New("rsa: invalid key type")
+	}
+}
+
+// WithWeakKeySize allows KeyGen to generate keys smaller than 2048 bits, such as rsa_1024 keys,
+// and KeyImport to import them as rsa keys. They are no longer considered secure and should only be
+// used for testing or legacy systems.
+func WithWeakKeySize[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		switch k := k.(type) {
+		case *PrivateKeyImpl[T]:
+			k.weakKeySize = true
+		case *PublicKeyImpl[T]:
+			k.weakKeySize = true
+		default:
+			return errors.New("rsa: invalid key type")
+		}
+		return nil
+	}
+}
+
+// bitsOf returns the key size of alg, or 0 for the rsa algorithm, whose keys may have any size.
+func bitsOf(alg types.Algorithm) (int, error) {
+	switch alg {
+	case types.Rsa:
+		return 0, nil
+	case types.Rsa1024:
+		return 1024, nil
+	case types.Rsa2048:
+		return 2048, nil
+	case types.Rsa3072:
+		return 3072, nil
+	case types.Rsa4096:
+		return 4096, nil
+	default:
+		return 0, fmt.Errorf("rsa: invalid algorithm: %v", alg)
+	}
+}
+
+// checkKey reports an error if pub is not of the key size of alg, or is smaller than minKeySize
+// for the rsa algorithm unless weak is set, or does not use the public exponent 65537. Small
+// exponents such as 3 are prone to attacks on poorly padded messages, and large ones make every
+// public key operation slow.
+func checkKey(pub *rsa.PublicKey, alg types.Algorithm, weak bool) error {
+	bits, err := bitsOf(alg)
+	if err != nil {
+		return err
+	}
+
+	switch size := pub.N.BitLen(); {
+	case bits != 0 && size != bits:
+		return fmt.Errorf("rsa: key size %d does not match algorithm %s", size, alg)
+	case bits == 0 && size < minKeySize && !weak:
+		return fmt.Errorf("rsa: refusing to import weak %d-bit key without WithWeakKeySize", size)
+	}
+
+	if pub.E != publicExponent {
+		return fmt.Errorf("rsa: unsupported public exponent %d, only %d is accepted", pub.E, publicExponent)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package rsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	_ "crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+// oaepParams are the OAEP parameters of a key. The MGF1 hash defaults to the OAEP hash when zero.
+type oaepParams struct {
+	hash    crypto.Hash
+	mgfHash crypto.Hash
+	label   []byte
+}
+
+// WithOAEPHash sets the hash used by OAEP encryption and decryption, one of SHA-1, SHA-256,
+// SHA-384 and SHA-512. The default is SHA-256. It is also used for MGF1 unless WithMGF1Hash is
+// given.
+func WithOAEPHash[T types.DataType](hash crypto.Hash) key.Option[T] {
+	return withOAEP[T](func(p *oaepParams) error {
+		if err := checkOAEPHash(hash); err != nil {
+			return err
+		}
+		p.hash = hash
+		return nil
+	})
+}
+
+// WithMGF1Hash sets the hash used by the OAEP mask generation function, one of SHA-1, SHA-256,
+// SHA-384 and SHA-512. Java and .NET use SHA-1 for MGF1 whatever the OAEP hash, so exchanging
+// ciphertexts with them needs WithMGF1Hash[T](crypto.SHA1).
+func WithMGF1Hash[T types.DataType](hash crypto.Hash) key.Option[T] {
+	return withOAEP[T](func(p *oaepParams) error {
+		if err := checkOAEPHash(hash); err != nil {
+			return err
+		}
+		p.mgfHash = hash
+		return nil
+	})
+}
+
+// WithOAEPLabel sets the OAEP label, which is bound to the ciphertext and must be the same for
+// encryption and decryption.
+func WithOAEPLabel[T types.DataType](label []byte) key.Option[T] {
+	return withOAEP[T](func(p *oaepParams) error {
+		p.label = append([]byte(nil), label...)
+		return nil
+	})
+}
+
+func withOAEP[T types.DataType](set func(p *oaepParams) error) key.Option[T] {
+	return func(k key.Key[T]) error {
+		switch k := k.(type) {
+		case *PrivateKeyImpl[T]:
+			return set(&k.oaep)
+		case *PublicKeyImpl[T]:
+			return set(&k.oaep)
+		default:
+			return errors.New("rsa: invalid key type")
+		}
+	}
+}
+
+func checkOAEPHash(hash crypto.Hash) error {
+	switch hash {
+	case crypto.SHA1, crypto.SHA256, crypto.SHA384, crypto.SHA512:
+		if hash.Available() {
+			return nil
+		}
+	}
+	return fmt.Errorf("rsa: unsupported oaep hash function: %v", hash)
+}
+
+// defaultOAEP returns the OAEP parameters used by earlier versions, SHA-256 without a label.
+func defaultOAEP() oaepParams {
+	return oaepParams{hash: crypto.SHA256}
+}
+
+// clone returns a copy of p that does not share the label.
+func (p oaepParams) clone() oaepParams {
+	p.label = append([]byte(nil), p.label...)
+	return p
+}
+
+func (p oaepParams) mgf() crypto.Hash {
+	if p.mgfHash == 0 {
+		return p.hash
+	}
+	return p.mgfHash
+}
+
+// encryptOAEP encrypts msg with RSAES-OAEP. crypto/rsa only encrypts with the same hash for OAEP
+// and MGF1 before Go 1.26, so other combinations are encoded here as in RFC 8017, Section 7.1.1.
+func encryptOAEP(pub *rsa.PublicKey, msg []byte, p oaepParams) ([]byte, error) {
+	if p.mgf() == p.hash {
+		return rsa.EncryptOAEP(p.hash.New(), rand.Reader, pub, msg, p.label)
+	}
+
+	k := pub.Size()
+	hLen := p.hash.Size()
+	if len(msg) > k-2*hLen-2 {
+		return nil, rsa.ErrMessageTooLong
+	}
+
+	em := make([]byte, k)
+	seed := em[1 : 1+hLen]
+	db := em[1+hLen:]
+
+	h := p.hash.New()
+	h.Write(p.label)
+	copy(db, h.Sum(nil))
+	db[len(db)-len(msg)-1] = 1
+	copy(db[len(db)-len(msg):], msg)
+
+	random, err := utils.RandomSize(hLen)
+	if err != nil {
+		return nil, err
+	}
+	copy(seed, random)
+
+	mgf1XOR(db, p.mgf(), seed)
+	mgf1XOR(seed, p.mgf(), db)
+
+	m := new(big.Int).SetBytes(em)
+	c := m.Exp(m, big.NewInt(int64(pub.E)), pub.N)
+
+	return c.FillBytes(make([]byte, k)), nil
+}
+
+// decryptOAEP decrypts an RSAES-OAEP ciphertext.
+func decryptOAEP(priv *rsa.PrivateKey, ciphertext []byte, p oaepParams) ([]byte, error) {
+	return priv.Decrypt(nil, ciphertext, &rsa.OAEPOptions{
+		Hash:    p.hash,
+		MGFHash: p.mgf(),
+		Label:   p.label,
+	})
+}
+
+// mgf1XOR XORs out with the MGF1 mask of seed, as specified in RFC 8017, Appendix B.2.1.
+func mgf1XOR(out []byte, hash crypto.Hash, seed []byte) {
+	var counter [4]byte
+	h := hash.New()
+
+	for done := 0; done < len(out); {
+		h.Reset()
+		h.Write(seed)
+		h.Write(counter[:])
+		digest := h.Sum(nil)
+
+		for i := 0; i < len(digest) && done < len(out); i++ {
+			out[done] ^= digest[i]
+			done++
+		}
+
+		for i := len(counter) - 1; i >= 0; i-- {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
+		}
+	}
+}
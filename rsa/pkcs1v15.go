@@ -0,0 +1,80 @@
+package rsa
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yakumioto/dipper/key"
+	"github.com/yakumioto/dipper/types"
+)
+
+// pkcs1v15Suffix is appended to the algorithm of signatures and ciphertexts using PKCS #1 v1.5
+// padding, e.g. rsa_2048_pkcs1v15, so that they are not mistaken for PSS signatures or OAEP
+// ciphertexts.
+const pkcs1v15Suffix = "_pkcs1v15"
+
+// WithPKCS1v15Signature makes the private key sign with PKCS #1 v1.5 padding (RSASSA-PKCS1-v1_5,
+// as used by JWT RS256 and XML signatures) instead of PSS. Public keys verify the padding recorded
+// in the signature.
+func WithPKCS1v15Signature[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		if r, ok := k.(*PrivateKeyImpl[T]); ok {
+			r.pkcs1v15Sign = true
+			return nil
+		}
+		return errors.New("rsa: invalid key type")
+	}
+}
+
+// WithPKCS1v15Encryption makes the public key encrypt with PKCS #1 v1.5 padding
+// (RSAES-PKCS1-v1_5) instead of OAEP. The ciphertexts can only be decrypted by private keys
+// imported with WithPKCS1v15Decryption. On a private key, it makes the keys returned by PublicKey
+// encrypt with PKCS #1 v1.5 padding, and enables WithPKCS1v15Decryption so that the private key
+// decrypts their ciphertexts.
+func WithPKCS1v15Encryption[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		switch k := k.(type) {
+		case *PrivateKeyImpl[T]:
+			k.pkcs1v15Encrypt = true
+			k.pkcs1v15Decrypt = true
+		case *PublicKeyImpl[T]:
+			k.pkcs1v15Encrypt = true
+		default:
+			return errors.New("rsa: invalid key type")
+		}
+		return nil
+	}
+}
+
+// WithPKCS1v15Decryption allows the private key to decrypt ciphertexts with PKCS #1 v1.5 padding,
+// in addition to OAEP. PKCS #1 v1.5 decryption is vulnerable to padding oracle attacks
+// (Bleichenbacher) when callers reveal whether decryption failed, so it is disabled by default and
+// should only be enabled for interoperability with systems that cannot use OAEP.
+func WithPKCS1v15Decryption[T types.DataType]() key.Option[T] {
+	return func(k key.Key[T]) error {
+		if r, ok := k.(*PrivateKeyImpl[T]); ok {
+			r.pkcs1v15Decrypt = true
+			return nil
+		}
+		return errors.New("rsa: invalid key type")
+	}
+}
+
+// envelopeAlgorithm returns the algorithm written to signatures and ciphertexts of the key.
+func envelopeAlgorithm(alg types.Algorithm, pkcs1v15 bool) string {
+	if pkcs1v15 {
+		return alg + pkcs1v15Suffix
+	}
+	return alg
+}
+
+// parseEnvelopeAlgorithm checks the algorithm of a signature or ciphertext against the key
+// algorithm, and reports whether it uses PKCS #1 v1.5 padding.
+func parseEnvelopeAlgorithm(algorithm string, alg types.Algorithm) (pkcs1v15 bool, err error) {
+	trimmed, pkcs1v15 := strings.CutSuffix(algorithm, pkcs1v15Suffix)
+	if trimmed != alg {
+		return false, fmt.Errorf("rsa: invalid algorithm type: %s", algorithm)
+	}
+	return pkcs1v15, nil
+}
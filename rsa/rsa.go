@@ -9,7 +9,6 @@ import (
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
-	"encoding/pem"
 	"errors"
 	"fmt"
 	"strings"
@@ -24,8 +23,16 @@ var (
 )
 
 type PrivateKeyImpl[T types.DataType] struct {
-	algorithm  types.Algorithm
-	privateKey *rsa.PrivateKey
+	algorithm       types.Algorithm
+	privateKey      *rsa.PrivateKey
+	pkcs1v15Sign    bool
+	pkcs1v15Encrypt bool
+	pkcs1v15Decrypt bool
+	oaep            oaepParams
+	format          Format
+	der             bool
+	keySize         int
+	weakKeySize     bool
 }
 
 func (r *PrivateKeyImpl[T]) Algorithm() types.Algorithm {
@@ -33,15 +40,21 @@ func (r *PrivateKeyImpl[T]) Algorithm() types.Algorithm {
 }
 
 func (r *PrivateKeyImpl[T]) Export() (T, error) {
+	if r.format == FormatPKCS8 {
+		pkcs8Encoded, err := x509.MarshalPKCS8PrivateKey(r.privateKey)
+		if err != nil {
+			return T(""), fmt.Errorf("rsa: failed to marshal private key: %w", err)
+		}
+
+		return T(encode(pkcs8Encoded, "PRIVATE KEY", r.der)), nil
+	}
+
 	pkcs1Encoded := x509.MarshalPKCS1PrivateKey(r.privateKey)
 	if pkcs1Encoded == nil {
 		return T(""), errors.New("rsa: failed to marshal private key")
 	}
 
-	return T(pem.EncodeToMemory(&pem.Block{
-		Type:  "PRIVATE RSA KEY",
-		Bytes: pkcs1Encoded,
-	})), nil
+	return T(encode(pkcs1Encoded, "RSA PRIVATE KEY", r.der)), nil
 }
 
 func (r *PrivateKeyImpl[T]) SKI() T {
@@ -50,9 +63,18 @@ func (r *PrivateKeyImpl[T]) SKI() T {
 }
 
 func (r *PrivateKeyImpl[T]) PublicKey() (key.Key[T], error) {
+	format := FormatPKCS1
+	if r.format == FormatPKCS8 {
+		format = FormatPKIX
+	}
+
 	return &PublicKeyImpl[T]{
-		publicKey: &r.privateKey.PublicKey,
-		algorithm: r.algorithm,
+		publicKey:       &r.privateKey.PublicKey,
+		algorithm:       r.algorithm,
+		pkcs1v15Encrypt: r.pkcs1v15Encrypt,
+		oaep:            r.oaep.clone(),
+		format:          format,
+		der:             r.der,
 	}, nil
 }
 
@@ -62,17 +84,37 @@ func (r *PrivateKeyImpl[T]) Sign(msg T) (T, error) {
 		return T(""), fmt.Errorf("rsa: failed to write message bytes to hash: %w", err)
 	}
 
-	digest := h.Sum(nil)
+	return r.signDigest(h.Sum(nil))
+}
+
+// SignDigest signs a SHA-256 digest.
+func (r *PrivateKeyImpl[T]) SignDigest(digest []byte, hash crypto.Hash) (T, error) {
+	if err := checkDigest(digest, hash); err != nil {
+		return T(""), err
+	}
+
+	return r.signDigest(digest)
+}
 
-	payload, err := rsa.SignPSS(rand.Reader, r.privateKey, crypto.SHA256, digest, &rsa.PSSOptions{
-		SaltLength: rsa.PSSSaltLengthAuto,
-	})
+func (r *PrivateKeyImpl[T]) signDigest(digest []byte) (T, error) {
+	var (
+		payload []byte
+		err     error
+	)
+
+	if r.pkcs1v15Sign {
+		payload, err = rsa.SignPKCS1v15(rand.Reader, r.privateKey, crypto.SHA256, digest)
+	} else {
+		payload, err = rsa.SignPSS(rand.Reader, r.privateKey, crypto.SHA256, digest, &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthAuto,
+		})
+	}
 	if err != nil {
 		return T(""), fmt.Errorf("rsa: failed to sign message: %w", err)
 	}
 
 	data := bytes.NewBuffer(nil)
-	data.WriteString(r.algorithm)
+	data.WriteString(envelopeAlgorithm(r.algorithm, r.pkcs1v15Sign))
 	data.WriteString(".")
 	data.WriteString(base64.RawStdEncoding.EncodeToString(digest))
 	data.WriteString(".")
@@ -85,29 +127,65 @@ func (r *PrivateKeyImpl[T]) Verify(_, _ T) (bool, error) {
 	return false, ErrUnsupportedMethod
 }
 
+func (r *PrivateKeyImpl[T]) VerifyDigest(_ []byte, _ crypto.Hash, _ T) (bool, error) {
+	return false, ErrUnsupportedMethod
+}
+
 func (r *PrivateKeyImpl[T]) Encrypt(_ T) (T, error) {
 	return T(""), ErrUnsupportedMethod
 }
 
 func (r *PrivateKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
 	dataBytes := utils.ToString(ciphertext)
-	parts := strings.SplitN(dataBytes, ".", 2)
-	if len(parts) != 2 {
+	parts := strings.SplitN(dataBytes, ".", 3)
+	if len(parts) < 2 {
 		return T(""), errors.New("rsa: invalid encrypted data structure")
 	}
 
-	algorithm, payload := parts[0], parts[1]
+	algorithm := parts[0]
+
+	pkcs1v15, err := parseEnvelopeAlgorithm(algorithm, r.algorithm)
+	if err != nil {
+		return T(""), err
+	}
 
-	if algorithm != r.algorithm {
-		return T(""), fmt.Errorf("rsa: invalid algorithm type: %s", algorithm)
+	if pkcs1v15 && !r.pkcs1v15Decrypt {
+		return T(""), errors.New("rsa: pkcs1v15 decryption is not enabled")
 	}
 
-	encryptedData, err := base64.RawStdEncoding.DecodeString(payload)
+	encryptedData, err := base64.RawStdEncoding.DecodeString(parts[1])
 	if err != nil {
 		return T(""), fmt.Errorf("rsa: decrypt failed to decode base64: %w", err)
 	}
 
-	data, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, r.privateKey, encryptedData, nil)
+	var data []byte
+	if len(parts) == 3 {
+		payload, err := base64.RawStdEncoding.DecodeString(parts[2])
+		if err != nil {
+			return T(""), fmt.Errorf("rsa: decrypt failed to decode base64: %w", err)
+		}
+
+		unwrap := func(wrappedKey []byte) ([]byte, error) {
+			return decryptOAEP(r.privateKey, wrappedKey, r.oaep)
+		}
+		if pkcs1v15 {
+			unwrap = r.decryptPKCS1v15DataKey
+		}
+
+		// a single error is returned whatever failed, so that hybrid PKCS #1 v1.5 ciphertexts are
+		// no padding oracle
+		if data, err = openHybrid(algorithm, encryptedData, payload, unwrap); err != nil {
+			return T(""), errHybridDecryption
+		}
+
+		return T(data), nil
+	}
+
+	if pkcs1v15 {
+		data, err = rsa.DecryptPKCS1v15(nil, r.privateKey, encryptedData)
+	} else {
+		data, err = decryptOAEP(r.privateKey, encryptedData, r.oaep)
+	}
 	if err != nil {
 		return T(""), fmt.Errorf("rsa: decrypt error: %w", err)
 	}
@@ -116,8 +194,13 @@ func (r *PrivateKeyImpl[T]) Decrypt(ciphertext T) (T, error) {
 }
 
 type PublicKeyImpl[T types.DataType] struct {
-	algorithm types.Algorithm
-	publicKey *rsa.PublicKey
+	algorithm       types.Algorithm
+	publicKey       *rsa.PublicKey
+	pkcs1v15Encrypt bool
+	oaep            oaepParams
+	format          Format
+	der             bool
+	weakKeySize     bool
 }
 
 func (r *PublicKeyImpl[T]) Algorithm() types.Algorithm {
@@ -125,11 +208,17 @@ func (r *PublicKeyImpl[T]) Algorithm() types.Algorithm {
 }
 
 func (r *PublicKeyImpl[T]) Export() (T, error) {
+	if r.format == FormatPKIX {
+		pkixEncoded, err := x509.MarshalPKIXPublicKey(r.publicKey)
+		if err != nil {
+			return T(""), fmt.Errorf("rsa: failed to marshal public key: %w", err)
+		}
+
+		return T(encode(pkixEncoded, "PUBLIC KEY", r.der)), nil
+	}
+
 	pkcs1Encoded := x509.MarshalPKCS1PublicKey(r.publicKey)
-	return T(pem.EncodeToMemory(&pem.Block{
-		Type:  "PUBLIC KEY",
-		Bytes: pkcs1Encoded,
-	})), nil
+	return T(encode(pkcs1Encoded, "RSA PUBLIC KEY", r.der)), nil
 }
 
 func (r *PublicKeyImpl[T]) SKI() T {
@@ -149,7 +238,29 @@ func (r *PublicKeyImpl[T]) Sign(_ T) (T, error) {
 	return T(""), ErrUnsupportedMethod
 }
 
+func (r *PublicKeyImpl[T]) SignDigest(_ []byte, _ crypto.Hash) (T, error) {
+	return T(""), ErrUnsupportedMethod
+}
+
 func (r *PublicKeyImpl[T]) Verify(msg, signature T) (bool, error) {
+	h := sha256.New()
+	if _, err := h.Write(utils.ToBytes(msg)); err != nil {
+		return false, fmt.Errorf("rsa: failed to compute message : %w", err)
+	}
+
+	return r.verifyDigest(h.Sum(nil), signature)
+}
+
+// VerifyDigest verifies a signature of a SHA-256 digest.
+func (r *PublicKeyImpl[T]) VerifyDigest(digest []byte, hash crypto.Hash, signature T) (bool, error) {
+	if err := checkDigest(digest, hash); err != nil {
+		return false, err
+	}
+
+	return r.verifyDigest(digest, signature)
+}
+
+func (r *PublicKeyImpl[T]) verifyDigest(digest []byte, signature T) (bool, error) {
 	dataBytes := utils.ToString(signature)
 
 	parts := strings.SplitN(dataBytes, ".", 3)
@@ -159,8 +270,9 @@ func (r *PublicKeyImpl[T]) Verify(msg, signature T) (bool, error) {
 
 	algorithm, encodedDigest, encodedSignature := parts[0], parts[1], parts[2]
 
-	if algorithm != r.algorithm {
-		return false, fmt.Errorf("rsa: invalid algorithm type: %s", algorithm)
+	pkcs1v15, err := parseEnvelopeAlgorithm(algorithm, r.algorithm)
+	if err != nil {
+		return false, err
 	}
 
 	providedDigest, err := base64.RawStdEncoding.DecodeString(encodedDigest)
@@ -173,68 +285,133 @@ func (r *PublicKeyImpl[T]) Verify(msg, signature T) (bool, error) {
 		return false, fmt.Errorf("rsa: decrypt provided signature failed to decode base64: %w", err)
 	}
 
-	h := sha256.New()
-	if _, err = h.Write(utils.ToBytes(msg)); err != nil {
-		return false, fmt.Errorf("rsa: failed to compute message : %w", err)
-	}
-	digest := h.Sum(nil)
-
 	if !bytes.Equal(digest, providedDigest) {
 		return false, fmt.Errorf("rsa: invalid digest")
 	}
 
-	if err = rsa.VerifyPSS(r.publicKey, crypto.SHA256, digest, providedSignature, &rsa.PSSOptions{
-		SaltLength: rsa.PSSSaltLengthAuto,
-	}); err != nil {
+	if pkcs1v15 {
+		err = rsa.VerifyPKCS1v15(r.publicKey, crypto.SHA256, digest, providedSignature)
+	} else {
+		err = rsa.VerifyPSS(r.publicKey, crypto.SHA256, digest, providedSignature, &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthAuto,
+		})
+	}
+	if err != nil {
 		return false, fmt.Errorf("rsa: failed to verify signature: %w", err)
 	}
 
 	return true, nil
 }
 
+// Encrypt encrypts plaintext with RSA, or with a random AES-256-GCM data key encrypted with RSA
+// when plaintext is too large for a single RSA block.
 func (r *PublicKeyImpl[T]) Encrypt(plaintext T) (T, error) {
-	payload, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, r.publicKey, utils.ToBytes(plaintext), nil)
+	algorithm := envelopeAlgorithm(r.algorithm, r.pkcs1v15Encrypt)
+	msg := utils.ToBytes(plaintext)
+
+	data := bytes.NewBuffer(nil)
+	data.WriteString(algorithm)
+
+	if len(msg) > r.maxPlaintextSize() {
+		wrappedKey, payload, err := sealHybrid(algorithm, msg, r.encrypt)
+		if err != nil {
+			return T(""), fmt.Errorf("rsa: failed to encrypt message: %w", err)
+		}
+
+		data.WriteString(".")
+		data.WriteString(base64.RawStdEncoding.EncodeToString(wrappedKey))
+		data.WriteString(".")
+		data.WriteString(base64.RawStdEncoding.EncodeToString(payload))
+
+		return T(data.Bytes()), nil
+	}
+
+	payload, err := r.encrypt(msg)
 	if err != nil {
 		return T(""), fmt.Errorf("rsa: failed to encrypt message: %w", err)
 	}
 
-	data := bytes.NewBuffer(nil)
-	data.WriteString(r.algorithm)
 	data.WriteString(".")
 	data.WriteString(base64.RawStdEncoding.EncodeToString(payload))
 
 	return T(data.Bytes()), nil
 }
 
+func (r *PublicKeyImpl[T]) encrypt(msg []byte) ([]byte, error) {
+	if r.pkcs1v15Encrypt {
+		return rsa.EncryptPKCS1v15(rand.Reader, r.publicKey, msg)
+	}
+	return encryptOAEP(r.publicKey, msg, r.oaep)
+}
+
+// maxPlaintextSize returns the length of the largest plaintext that fits in a single RSA block.
+func (r *PublicKeyImpl[T]) maxPlaintextSize() int {
+	if r.pkcs1v15Encrypt {
+		return r.publicKey.Size() - 11
+	}
+	return r.publicKey.Size() - 2*r.oaep.hash.Size() - 2
+}
+
 func (r *PublicKeyImpl[T]) Decrypt(_ T) (T, error) {
 	return T(""), ErrUnsupportedMethod
 }
 
+// checkDigest reports an error if digest is not a SHA-256 digest, the hash used to sign messages.
+func checkDigest(digest []byte, hash crypto.Hash) error {
+	if hash != crypto.SHA256 {
+		return fmt.Errorf("rsa: digest hash %v does not match key hash %v", hash, crypto.SHA256)
+	}
+
+	if len(digest) != hash.Size() {
+		return fmt.Errorf("rsa: invalid digest length: want %d bytes, got %d", hash.Size(), len(digest))
+	}
+	return nil
+}
+
+func init() {
+	for _, alg := range []types.Algorithm{types.Rsa, types.Rsa1024, types.Rsa2048, types.Rsa3072, types.Rsa4096} {
+		key.Register[string](alg, new(KeyImportImpl[string]), new(KeyGeneratorImpl[string]))
+		key.Register[[]byte](alg, new(KeyImportImpl[[]byte]), new(KeyGeneratorImpl[[]byte]))
+	}
+}
+
 type KeyGeneratorImpl[T types.DataType] struct{}
 
 func (r *KeyGeneratorImpl[T]) KeyGen(alg types.Algorithm, opts ...key.Option[T]) (key.Key[T], error) {
-	var bits int
-
-	switch alg {
-	case types.Rsa1024:
-		bits = 1024
-	case types.Rsa2048:
-		bits = 2048
-	case types.Rsa4096:
-		bits = 4096
-	default:
-		return nil, fmt.Errorf("rsa: invalid algorithm: %v", alg)
+	bits, err := bitsOf(alg)
+	if err != nil {
+		return nil, err
 	}
 
-	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
-	if err != nil {
+	k := &PrivateKeyImpl[T]{
+		algorithm: alg,
+		oaep:      defaultOAEP(),
+	}
+
+	for _, opt := range opts {
+		if err = opt(k); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case k.keySize != 0 && bits != 0 && k.keySize != bits:
+		return nil, fmt.Errorf("rsa: key size %d does not match algorithm %s", k.keySize, alg)
+	case k.keySize != 0:
+		bits = k.keySize
+	case bits == 0:
+		bits = minKeySize
+	}
+
+	if bits < minKeySize && !k.weakKeySize {
+		return nil, fmt.Errorf("rsa: refusing to generate weak %d-bit key without WithWeakKeySize", bits)
+	}
+
+	if k.privateKey, err = rsa.GenerateKey(rand.Reader, bits); err != nil {
 		return nil, fmt.Errorf("rsa: failed to generate private key: %w", err)
 	}
 
-	return &PrivateKeyImpl[T]{
-		algorithm:  alg,
-		privateKey: privateKey,
-	}, nil
+	return k, nil
 }
 
 type KeyImportImpl[T types.DataType] struct{}
@@ -245,26 +422,52 @@ func (r *KeyImportImpl[T]) KeyImport(raw interface{}, alg types.Algorithm, opts
 		return nil, err
 	}
 
-	block, _ := pem.Decode(data)
-	if block == nil {
-		return nil, fmt.Errorf("rsa: failed to decode pem block")
+	pk, err := parseKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("rsa: failed to parse key: %w", err)
 	}
 
-	privKey, privErr := x509.ParsePKCS1PrivateKey(block.Bytes)
-	if privErr == nil {
-		return &PrivateKeyImpl[T]{
+	var (
+		k   key.Key[T]
+		pub *rsa.PublicKey
+	)
+
+	switch pk := pk.(type) {
+	case *rsa.PrivateKey:
+		k = &PrivateKeyImpl[T]{
 			algorithm:  alg,
-			privateKey: privKey,
-		}, nil
+			privateKey: pk,
+			oaep:       defaultOAEP(),
+		}
+		pub = &pk.PublicKey
+	case *rsa.PublicKey:
+		k = &PublicKeyImpl[T]{
+			algorithm: alg,
+			publicKey: pk,
+			oaep:      defaultOAEP(),
+		}
+		pub = pk
+	default:
+		return nil, errors.New("rsa: unsupported key type")
 	}
 
-	pubKey, pubErr := x509.ParsePKCS1PublicKey(block.Bytes)
-	if pubErr == nil {
-		return &PublicKeyImpl[T]{
-			algorithm: alg,
-			publicKey: pubKey,
-		}, nil
+	for _, opt := range opts {
+		if err = opt(k); err != nil {
+			return nil, err
+		}
+	}
+
+	var weak bool
+	switch k := k.(type) {
+	case *PrivateKeyImpl[T]:
+		weak = k.weakKeySize
+	case *PublicKeyImpl[T]:
+		weak = k.weakKeySize
+	}
+
+	if err = checkKey(pub, alg, weak); err != nil {
+		return nil, err
 	}
 
-	return nil, fmt.Errorf("rsa: failed to parse private key error: %w, public key error: %w", privErr, pubErr)
+	return k, nil
 }
@@ -0,0 +1,294 @@
+// Package stream implements chunked authenticated encryption of data streams with the STREAM
+// construction (Hoang, Reyhanitabar, Rogaway and Vizár, "Online Authenticated-Encryption and its
+// Nonce-Reuse Misuse-Resistance").
+//
+// An encrypted stream starts with a header made of the algorithm name, a "." separator, a random
+// salt and a random nonce prefix. Every stream is encrypted with its own key derived from the key
+// and the salt with HKDF-SHA256. The plaintext is split into segments of SegmentSize bytes, and
+// each segment is sealed with the nonce
+//
+//	nonce prefix || big-endian uint32 segment counter || last segment flag
+//
+// and the header as associated data. The counter detects reordered segments and the flag detects
+// truncated streams.
+package stream
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/yakumioto/dipper/types"
+	"github.com/yakumioto/dipper/utils"
+)
+
+const (
+	// SegmentSize is the size of a plaintext segment. Only the last segment can be shorter.
+	SegmentSize = 64 * 1024
+
+	saltSize = 32
+)
+
+var (
+	ErrAuthenticationFailed = errors.New("stream: segment authentication failed")
+	ErrTruncated            = errors.New("stream: truncated stream")
+	ErrTooLong              = errors.New("stream: too many segments")
+	ErrClosed               = errors.New("stream: write to closed writer")
+)
+
+// AEADFunc creates a cipher.AEAD from a key of the size given to NewWriter or NewReader.
+type AEADFunc func(key []byte) (cipher.AEAD, error)
+
+// NewWriter returns a WriteCloser that encrypts everything written to it and writes the encrypted
+// stream to w. Close must be called to write the last segment, and it does not close w.
+func NewWriter(w io.Writer, alg types.Algorithm, key []byte, newAEAD AEADFunc) (io.WriteCloser, error) {
+	probe, err := newAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("stream: failed to create cipher: %w", err)
+	}
+
+	salt, err := utils.RandomSize(saltSize)
+	if err != nil {
+		return nil, fmt.Errorf("stream: failed to generate random salt: %w", err)
+	}
+
+	prefix, err := utils.RandomSize(probe.NonceSize() - 5)
+	if err != nil {
+		return nil, fmt.Errorf("stream: failed to generate random nonce prefix: %w", err)
+	}
+
+	header := bytes.NewBuffer(nil)
+	header.WriteString(alg)
+	header.WriteString(".")
+	header.Write(salt)
+	header.Write(prefix)
+
+	aead, err := streamAEAD(alg, key, salt, newAEAD)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = w.Write(header.Bytes()); err != nil {
+		return nil, fmt.Errorf("stream: failed to write header: %w", err)
+	}
+
+	return &writer{
+		w:      w,
+		aead:   aead,
+		header: header.Bytes(),
+		nonce:  newNonce(prefix),
+		buf:    make([]byte, 0, SegmentSize),
+	}, nil
+}
+
+// NewReader returns a Reader that decrypts the encrypted stream read from r. Read returns
+// ErrAuthenticationFailed if a segment was modified, reordered or removed, including segments
+// removed from the end of the stream, and ErrTruncated if the stream ends inside a segment tag.
+func NewReader(r io.Reader, alg types.Algorithm, key []byte, newAEAD AEADFunc) (io.Reader, error) {
+	probe, err := newAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("stream: failed to create cipher: %w", err)
+	}
+
+	header := make([]byte, len(alg)+1+saltSize+probe.NonceSize()-5)
+	if _, err = io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("stream: failed to read header: %w", err)
+	}
+
+	if string(header[:len(alg)+1]) != alg+"." {
+		return nil, errors.New("stream: invalid algorithm type")
+	}
+
+	salt, prefix := header[len(alg)+1:len(alg)+1+saltSize], header[len(alg)+1+saltSize:]
+
+	aead, err := streamAEAD(alg, key, salt, newAEAD)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reader{
+		r:      r,
+		aead:   aead,
+		header: header,
+		nonce:  newNonce(prefix),
+		// one extra byte is read ahead to find out whether a segment is the last one
+		buf: make([]byte, 0, SegmentSize+aead.Overhead()+1),
+	}, nil
+}
+
+// streamAEAD derives the key of a single stream from the key and the salt of the stream header.
+func streamAEAD(alg types.Algorithm, key, salt []byte, newAEAD AEADFunc) (cipher.AEAD, error) {
+	streamKey := make([]byte, len(key))
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, []byte(alg)), streamKey); err != nil {
+		return nil, fmt.Errorf("stream: failed to derive stream key: %w", err)
+	}
+
+	aead, err := newAEAD(streamKey)
+	if err != nil {
+		return nil, fmt.Errorf("stream: failed to create cipher: %w", err)
+	}
+
+	return aead, nil
+}
+
+type nonce struct {
+	value   []byte
+	counter uint64
+}
+
+func newNonce(prefix []byte) *nonce {
+	value := make([]byte, len(prefix)+5)
+	copy(value, prefix)
+	return &nonce{value: value}
+}
+
+// next returns the nonce of the next segment.
+func (n *nonce) next(last bool) ([]byte, error) {
+	if n.counter > math.MaxUint32 {
+		return nil, ErrTooLong
+	}
+
+	binary.BigEndian.PutUint32(n.value[len(n.value)-5:], uint32(n.counter))
+	n.value[len(n.value)-1] = 0
+	if last {
+		n.value[len(n.value)-1] = 1
+	}
+	n.counter++
+
+	return n.value, nil
+}
+
+type writer struct {
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	nonce  *nonce
+	buf    []byte
+	out    []byte
+	closed bool
+}
+
+func (s *writer) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, ErrClosed
+	}
+
+	n := 0
+	for len(p) > 0 {
+		// a full segment is only sealed once more data arrives, so that the last segment is never
+		// empty unless the whole stream is
+		if len(s.buf) == SegmentSize {
+			if err := s.seal(false); err != nil {
+				return n, err
+			}
+		}
+
+		m := copy(s.buf[len(s.buf):SegmentSize], p)
+		s.buf = s.buf[:len(s.buf)+m]
+		p = p[m:]
+		n += m
+	}
+
+	return n, nil
+}
+
+func (s *writer) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	return s.seal(true)
+}
+
+func (s *writer) seal(last bool) error {
+	nonce, err := s.nonce.next(last)
+	if err != nil {
+		return err
+	}
+
+	s.out = s.aead.Seal(s.out[:0], nonce, s.buf, s.header)
+	s.buf = s.buf[:0]
+
+	if _, err = s.w.Write(s.out); err != nil {
+		return fmt.Errorf("stream: failed to write segment: %w", err)
+	}
+
+	return nil
+}
+
+type reader struct {
+	r         io.Reader
+	aead      cipher.AEAD
+	header    []byte
+	nonce     *nonce
+	buf       []byte
+	plaintext []byte
+	out       []byte
+	err       error
+}
+
+func (s *reader) Read(p []byte) (int, error) {
+	for len(s.out) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		s.err = s.open()
+	}
+
+	n := copy(p, s.out)
+	s.out = s.out[n:]
+
+	return n, nil
+}
+
+// open reads and decrypts the next segment. It returns io.EOF after the last segment.
+func (s *reader) open() error {
+	segmentSize := SegmentSize + s.aead.Overhead()
+
+	n, err := io.ReadFull(s.r, s.buf[len(s.buf):segmentSize+1])
+	s.buf = s.buf[:len(s.buf)+n]
+
+	last := false
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		last = true
+	case err != nil:
+		return fmt.Errorf("stream: failed to read segment: %w", err)
+	}
+
+	if last && len(s.buf) < s.aead.Overhead() {
+		return ErrTruncated
+	}
+
+	segment := s.buf
+	if !last {
+		segment = s.buf[:segmentSize]
+	}
+
+	nonce, err := s.nonce.next(last)
+	if err != nil {
+		return err
+	}
+
+	s.plaintext, err = s.aead.Open(s.plaintext[:0], nonce, segment, s.header)
+	if err != nil {
+		return ErrAuthenticationFailed
+	}
+	s.out = s.plaintext
+
+	if last {
+		return io.EOF
+	}
+
+	s.buf = append(s.buf[:0], s.buf[segmentSize:]...)
+
+	return nil
+}
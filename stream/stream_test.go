@@ -0,0 +1,168 @@
+package stream
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAlgorithm = "aes_gcm_256"
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func encryptStream(t *testing.T, key, plaintext []byte) []byte {
+	buf := bytes.NewBuffer(nil)
+
+	w, err := NewWriter(buf, testAlgorithm, key, newGCM)
+	assert.NoErrorf(t, err, "NewWriter failed: %s", err)
+
+	// write in uneven pieces to exercise the segment buffering
+	for len(plaintext) > 0 {
+		n := min(len(plaintext), 1000)
+		_, err = w.Write(plaintext[:n])
+		assert.NoErrorf(t, err, "Write failed: %s", err)
+		plaintext = plaintext[n:]
+	}
+
+	assert.NoError(t, w.Close(), "Close failed")
+
+	return buf.Bytes()
+}
+
+func decryptStream(key, ciphertext []byte) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(ciphertext), testAlgorithm, key, newGCM)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+func TestEncryptAndDecrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+
+	for _, size := range []int{0, 1, SegmentSize - 1, SegmentSize, SegmentSize + 1, 3*SegmentSize + 7} {
+		plaintext := make([]byte, size)
+		for i := range plaintext {
+			plaintext[i] = byte(i)
+		}
+
+		ciphertext := encryptStream(t, key, plaintext)
+		assert.True(t, bytes.HasPrefix(ciphertext, []byte(testAlgorithm+".")), "header should name the algorithm")
+
+		decrypted, err := decryptStream(key, ciphertext)
+		assert.NoErrorf(t, err, "decrypt failed for size %d: %s", size, err)
+		assert.Equal(t, plaintext, decrypted, "decrypt failed for size %d", size)
+	}
+}
+
+func TestEncryptIsRandomized(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+
+	assert.NotEqual(t, encryptStream(t, key, []byte("hello world")), encryptStream(t, key, []byte("hello world")),
+		"streams should use a fresh salt and nonce prefix")
+}
+
+func TestTamperedStream(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+	plaintext := bytes.Repeat([]byte("hello world"), SegmentSize/4)
+	ciphertext := encryptStream(t, key, plaintext)
+
+	headerSize := len(testAlgorithm) + 1 + saltSize + 12 - 5
+	segmentSize := SegmentSize + 16
+	assert.Equal(t, headerSize+2*segmentSize+(len(plaintext)-2*SegmentSize)+16, len(ciphertext), "unexpected stream size")
+
+	header := ciphertext[:headerSize]
+	segments := [][]byte{
+		ciphertext[headerSize : headerSize+segmentSize],
+		ciphertext[headerSize+segmentSize : headerSize+2*segmentSize],
+		ciphertext[headerSize+2*segmentSize:],
+	}
+
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(append([][]byte{header}, parts...), nil)
+	}
+
+	tcs := []struct {
+		name       string
+		ciphertext []byte
+		err        error
+	}{
+		{
+			name:       "reordered segments",
+			ciphertext: join(segments[1], segments[0], segments[2]),
+			err:        ErrAuthenticationFailed,
+		},
+		{
+			name:       "dropped last segment",
+			ciphertext: join(segments[0], segments[1]),
+			err:        ErrAuthenticationFailed,
+		},
+		{
+			name:       "dropped middle segment",
+			ciphertext: join(segments[0], segments[2]),
+			err:        ErrAuthenticationFailed,
+		},
+		{
+			name:       "truncated inside a segment",
+			ciphertext: join(segments[0], segments[1], segments[2][:len(segments[2])-1]),
+			err:        ErrAuthenticationFailed,
+		},
+		{
+			name:       "truncated after the header",
+			ciphertext: join(),
+			err:        ErrTruncated,
+		},
+		{
+			name:       "appended segment",
+			ciphertext: join(segments[0], segments[1], segments[2], segments[2]),
+			err:        ErrAuthenticationFailed,
+		},
+		{
+			name:       "modified header",
+			ciphertext: append(append([]byte{}, ciphertext[:headerSize-1]...), append([]byte{ciphertext[headerSize-1] ^ 1}, ciphertext[headerSize:]...)...),
+			err:        ErrAuthenticationFailed,
+		},
+	}
+
+	for _, tc := range tcs {
+		_, err := decryptStream(key, tc.ciphertext)
+		assert.ErrorIsf(t, err, tc.err, "%s should fail", tc.name)
+	}
+
+	for _, i := range []int{headerSize, headerSize + segmentSize + 100, len(ciphertext) - 1} {
+		tampered := bytes.Clone(ciphertext)
+		tampered[i] ^= 0x01
+
+		_, err := decryptStream(key, tampered)
+		assert.ErrorIs(t, err, ErrAuthenticationFailed, "tampered stream should fail")
+	}
+
+	_, err := decryptStream(bytes.Repeat([]byte{0x24}, 32), ciphertext)
+	assert.ErrorIs(t, err, ErrAuthenticationFailed, "decrypt with wrong key should fail")
+
+	_, err = NewReader(bytes.NewReader(ciphertext), "aes_gcm_128", key, newGCM)
+	assert.Error(t, err, "NewReader with wrong algorithm should fail")
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	w, err := NewWriter(io.Discard, testAlgorithm, bytes.Repeat([]byte{0x42}, 32), newGCM)
+	assert.NoErrorf(t, err, "NewWriter failed: %s", err)
+
+	assert.NoError(t, w.Close(), "Close failed")
+	assert.NoError(t, w.Close(), "Close twice failed")
+
+	_, err = w.Write([]byte("hello world"))
+	assert.ErrorIs(t, err, ErrClosed, "Write after Close should fail")
+}
@@ -25,16 +25,55 @@ const (
 	AesGcm128 Algorithm = "aes_gcm_128"
 	AesGcm192 Algorithm = "aes_gcm_192"
 	AesGcm256 Algorithm = "aes_gcm_256"
+
+	AesCbcHmac128 Algorithm = "aes_cbc_hmac_128"
+	AesCbcHmac192 Algorithm = "aes_cbc_hmac_192"
+	AesCbcHmac256 Algorithm = "aes_cbc_hmac_256"
+
+	AesGcmSiv128 Algorithm = "aes_gcm_siv_128"
+	AesGcmSiv256 Algorithm = "aes_gcm_siv_256"
+
+	// AES-SIV algorithms are named after the combined key size as in RFC 5297,
+	// e.g. AesSiv256 uses two AES-128 keys.
+	AesSiv256 Algorithm = "aes_siv_256"
+	AesSiv384 Algorithm = "aes_siv_384"
+	AesSiv512 Algorithm = "aes_siv_512"
+
+	// unauthenticated AES stream modes, provided for interoperability only.
+	AesCtr128 Algorithm = "aes_ctr_128"
+	AesCtr192 Algorithm = "aes_ctr_192"
+	AesCtr256 Algorithm = "aes_ctr_256"
+	AesCfb128 Algorithm = "aes_cfb_128"
+	AesCfb192 Algorithm = "aes_cfb_192"
+	AesCfb256 Algorithm = "aes_cfb_256"
+	AesOfb128 Algorithm = "aes_ofb_128"
+	AesOfb192 Algorithm = "aes_ofb_192"
+	AesOfb256 Algorithm = "aes_ofb_256"
+
+	// AES key wrap (RFC 3394) and AES key wrap with padding (RFC 5649).
+	AesKw128  Algorithm = "aes_kw_128"
+	AesKw192  Algorithm = "aes_kw_192"
+	AesKw256  Algorithm = "aes_kw_256"
+	AesKwp128 Algorithm = "aes_kwp_128"
+	AesKwp192 Algorithm = "aes_kwp_192"
+	AesKwp256 Algorithm = "aes_kwp_256"
+
 	Chacha20  Algorithm = "chacha20"
 	XChacha20 Algorithm = "x_chacha20"
+
+	Chacha20Poly1305  Algorithm = "chacha20_poly1305"
+	XChacha20Poly1305 Algorithm = "xchacha20_poly1305"
 )
 
 // asymmetric algorithms type
 const (
-	EcdsaP256 Algorithm = "ecdsa_p256"
-	EcdsaP384 Algorithm = "ecdsa_p384"
-	EcdsaP521 Algorithm = "ecdsa_p521"
-	Rsa1024   Algorithm = "rsa_1024"
-	Rsa2048   Algorithm = "rsa_2048"
-	Rsa4096   Algorithm = "rsa_4096"
+	EcdsaP256      Algorithm = "ecdsa_p256"
+	EcdsaP384      Algorithm = "ecdsa_p384"
+	EcdsaP521      Algorithm = "ecdsa_p521"
+	EcdsaSecp256k1 Algorithm = "ecdsa_secp256k1"
+	Rsa            Algorithm = "rsa"
+	Rsa1024        Algorithm = "rsa_1024"
+	Rsa2048        Algorithm = "rsa_2048"
+	Rsa3072        Algorithm = "rsa_3072"
+	Rsa4096        Algorithm = "rsa_4096"
 )
@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"errors"
+	"reflect"
 
 	"golang.org/x/crypto/pbkdf2"
 
 	"github.com/yakumioto/dipper/types"
 )
 
+var ErrInvalidPadding = errors.New("invalid pkcs7 padding")
+
 type RandomSizeFunc func(len int) ([]byte, error)
 
 var (
@@ -29,6 +33,8 @@ func ExtendKey(key []byte, keyLen int) []byte {
 	return pbkdf2.Key(key, nil, 1, keyLen, sha256.New)
 }
 
+// ToKeyBytes returns the bytes of a key given as a string or a byte slice, including named types
+// such as type Token string.
 func ToKeyBytes(key interface{}) ([]byte, error) {
 	switch key := key.(type) {
 	case []byte:
@@ -41,20 +47,21 @@ func ToKeyBytes(key interface{}) ([]byte, error) {
 			return nil, errors.New("empty key string")
 		}
 		return []byte(key), nil
+	}
+
+	v := reflect.ValueOf(key)
+	switch {
+	case v.Kind() == reflect.String:
+		return ToKeyBytes(v.String())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return ToKeyBytes(v.Bytes())
 	default:
 		return nil, errors.New("key type not supported")
 	}
 }
 
 func ToString[T types.DataType](b T) string {
-	switch b := any(b).(type) {
-	case []byte:
-		return string(b)
-	case string:
-		return b
-	}
-
-	return ""
+	return string(b)
 }
 
 func ToHexString[T types.DataType](b T) string {
@@ -64,12 +71,7 @@ func ToHexString[T types.DataType](b T) string {
 }
 
 func ToBytes[T types.DataType](s T) []byte {
-	switch b := any(s).(type) {
-	case string:
-		return []byte(b)
-	default:
-		return b.([]byte)
-	}
+	return []byte(s)
 }
 
 func Pkcs7Padding[T types.DataType](src T, blockSize int) []byte {
@@ -84,13 +86,27 @@ func Pkcs7Padding[T types.DataType](src T, blockSize int) []byte {
 	return paddedData
 }
 
-func Pkcs7UnPadding[T types.DataType](src T) T {
+// Pkcs7UnPadding removes and validates the PKCS#7 padding of src. The length of src must be a
+// non-zero multiple of blockSize, and the padding bytes are checked in constant time so that
+// malformed input cannot be distinguished by timing.
+func Pkcs7UnPadding[T types.DataType](src T, blockSize int) (T, error) {
 	srcBytes := ToBytes[T](src)
 	length := len(srcBytes)
-	if length == 0 {
-		return src
+	if blockSize <= 0 || blockSize > 255 || length == 0 || length%blockSize != 0 {
+		return T(""), ErrInvalidPadding
 	}
 
 	unPadding := int(srcBytes[length-1])
-	return T(srcBytes[:(length - unPadding)])
+	valid := subtle.ConstantTimeLessOrEq(1, unPadding) & subtle.ConstantTimeLessOrEq(unPadding, blockSize)
+	for i := 1; i <= blockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, unPadding)
+		matched := subtle.ConstantTimeByteEq(srcBytes[length-i], byte(unPadding))
+		valid &= subtle.ConstantTimeSelect(inPadding, matched, 1)
+	}
+
+	if valid != 1 {
+		return T(""), ErrInvalidPadding
+	}
+
+	return T(srcBytes[:(length - unPadding)]), nil
 }
@@ -18,6 +18,21 @@ func TestToKeyBytes(t *testing.T) {
 	// test key by struct
 	_, err = ToKeyBytes(struct{}{})
 	assert.Error(t, err)
+
+	// test key by named string and byte types
+	type token string
+	type rawToken []byte
+
+	data, err := ToKeyBytes(token("123456"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("123456"), data)
+
+	data, err = ToKeyBytes(rawToken("123456"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("123456"), data)
+
+	_, err = ToKeyBytes(token(""))
+	assert.Error(t, err)
 }
 
 func TestToString(t *testing.T) {
@@ -78,24 +93,41 @@ func TestPkcs7UnPadding(t *testing.T) {
 	// Test case 1: Unpadding a string
 	src := []byte("hello world\x05\x05\x05\x05\x05")
 	expected := []byte("hello world")
-	result := Pkcs7UnPadding(src)
+	result, err := Pkcs7UnPadding(src, 16)
+	assert.NoError(t, err)
 	assert.Equal(t, expected, result)
 
 	// Test case 2: Unpadding a byte slice
 	src2 := []byte("test data\x07\x07\x07\x07\x07\x07\x07")
 	expected2 := []byte("test data")
-	result2 := Pkcs7UnPadding(src2)
+	result2, err := Pkcs7UnPadding(src2, 8)
+	assert.NoError(t, err)
 	assert.Equal(t, expected2, result2)
 
 	// Test case 3: Unpadding an empty string
 	src3 := []byte("\x08\x08\x08\x08\x08\x08\x08\x08")
 	expected3 := []byte("")
-	result3 := Pkcs7UnPadding(src3)
+	result3, err := Pkcs7UnPadding(src3, 8)
+	assert.NoError(t, err)
 	assert.Equal(t, expected3, result3)
 
 	// Test case 4: empty byte slice
-	src4 := []byte{}
-	expected4 := []byte{}
-	result4 := Pkcs7UnPadding(src4)
-	assert.Equal(t, expected4, result4)
+	_, err = Pkcs7UnPadding([]byte{}, 8)
+	assert.ErrorIs(t, err, ErrInvalidPadding)
+
+	// Test case 5: padding byte is zero
+	_, err = Pkcs7UnPadding([]byte("test da\x00"), 8)
+	assert.ErrorIs(t, err, ErrInvalidPadding)
+
+	// Test case 6: padding byte larger than the block size
+	_, err = Pkcs7UnPadding([]byte("test da\x09"), 8)
+	assert.ErrorIs(t, err, ErrInvalidPadding)
+
+	// Test case 7: padding bytes do not match
+	_, err = Pkcs7UnPadding([]byte("test d\x01\x02"), 8)
+	assert.ErrorIs(t, err, ErrInvalidPadding)
+
+	// Test case 8: input is not a multiple of the block size
+	_, err = Pkcs7UnPadding("test\x01", 8)
+	assert.ErrorIs(t, err, ErrInvalidPadding)
 }